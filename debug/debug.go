@@ -2,6 +2,11 @@
 // consumer provided by package goff.
 //
 //     Usage: go run debug/debug.go --clientKey=<key> --clientSecret=<secret>
+//
+// URLs starting with "/" are requested relative to the --baseURL flag, which
+// defaults to goff.YahooBaseURL. When the --fixtures flag names a directory,
+// each response is also written to it as a fixture that can be served by
+// gofftest.Replayer.
 package main
 
 import (
@@ -9,9 +14,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/e0/goff"
+	"github.com/e0/goff/gofftest"
 )
 
 func main() {
@@ -27,23 +34,26 @@ func main() {
 		"Required client OAuth secret. "+
 			"See http://developer.yahoo.com/fantasysports/guide/GettingStarted.html"+
 			" for more information")
+	baseURL := flag.String(
+		"baseURL",
+		goff.YahooBaseURL,
+		"Base URL of the fantasy sports API used for URLs starting with '/'")
+	fixtures := flag.String(
+		"fixtures",
+		"",
+		"Optional directory where responses are recorded as test fixtures")
 	flag.Parse()
 	if len(*clientKey) == 0 || len(*clientSecret) == 0 {
 		fmt.Println("Usage: debug --clientKey=\"<key>\" --clientSecret=\"<secret>\"")
 		os.Exit(1)
 	}
 
-	fmt.Fprintf(
-		os.Stdout,
-		"clientKey: %s, clientSecret: %s\n",
-		*clientKey,
-		*clientSecret)
-
 	consumer := goff.GetConsumer(*clientKey, *clientSecret)
 
 	requestToken, url, err := consumer.GetRequestTokenAndUrl("oob")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting request token: %s", err)
+		fmt.Fprintf(os.Stderr, "Error getting request token: %s\n",
+			goff.Redact(err.Error()))
 		os.Exit(1)
 	}
 
@@ -56,7 +66,8 @@ func main() {
 
 	accessToken, err := consumer.AuthorizeToken(requestToken, verificationCode)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error authorizing token: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error authorizing token: %s\n",
+			goff.Redact(err.Error()))
 		os.Exit(1)
 	}
 
@@ -73,12 +84,21 @@ func main() {
 		if url == "exit" || url == "" {
 			break
 		}
+		if strings.HasPrefix(url, "/") {
+			url = strings.TrimRight(*baseURL, "/") + url
+		}
 
 		start := time.Now()
 		response, err := consumer.Get(url, map[string]string{}, accessToken)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting content: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error getting content: %s\n",
+				goff.Redact(err.Error()))
 		} else {
+			if *fixtures != "" {
+				if err := gofftest.WriteFixture(*fixtures, url, response); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing fixture: %s\n", err)
+				}
+			}
 			defer response.Body.Close()
 			bits, err := ioutil.ReadAll(response.Body)
 			if err != nil {
@@ -20,18 +20,32 @@
 //         See http://developer.yahoo.com/fantasysports/guide/ for the type
 //         requests that can be made.
 //
+// The data structures holding fantasy content can also be encoded as JSON
+// using encoding/json. The keys are the snake_case names of the elements in
+// the API responses, with lists named after the list element rather than its
+// items (e.g. "players" for League.Players). Totals and ranks are encoded as
+// numbers, under "total" and "rank", and the strings they are parsed from are
+// omitted. This representation is stable; fields will only be added to it.
+//
 // The goff client is currently in early stage development and the API is
 // subject to change at any moment.
 package goff
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mrjones/oauth"
@@ -46,8 +60,9 @@ const (
 	// NflGameKey represents the current year's Yahoo fantasy football game
 	NflGameKey = "nfl"
 
-	// YahooBaseURL is the base URL for all calls to Yahoo's fantasy sports API
-	YahooBaseURL = "http://fantasysports.yahooapis.com/fantasy/v2"
+	// YahooBaseURL is the default base URL for all calls to Yahoo's fantasy
+	// sports API
+	YahooBaseURL = "https://fantasysports.yahooapis.com/fantasy/v2"
 
 	// YahooRequestTokenURL is used to create OAuth request tokens
 	YahooRequestTokenURL = "https://api.login.yahoo.com/oauth/v2/get_request_token"
@@ -60,11 +75,52 @@ const (
 	YahooGetTokenURL = "https://api.login.yahoo.com/oauth/v2/get_token"
 )
 
+// YahooOAuthEndpoints are the endpoints used by GetConsumer to authorize
+// access to Yahoo's fantasy sports API.
+var YahooOAuthEndpoints = OAuthEndpoints{
+	RequestTokenURL:   YahooRequestTokenURL,
+	AuthorizeTokenURL: YahooAuthTokenURL,
+	AccessTokenURL:    YahooGetTokenURL,
+}
+
 // ErrAccessDenied is returned when the user does not have permision to
 // access the requested resource.
 var ErrAccessDenied = errors.New(
 	"user does not have permission to access the requested resource")
 
+// StaleContentError is returned by cached clients created with
+// WithStaleOnError when a request fails but content cached for it during the
+// previous cache period is available.
+//
+// The stale content is returned along with the error, so GetFantasyContent
+// returns the cached content itself and the convenience functions of Client,
+// such as GetTeam, return results read from it. Functions making several
+// requests, such as GetPlayersStatsHistory, return partial results combining
+// up to date and stale content when only some of their requests fail.
+type StaleContentError struct {
+	// Content cached for the request during the previous cache period
+	Content *FantasyContent
+	// The error that caused the request to fail
+	Err error
+}
+
+// Error describes the failure that caused stale content to be returned.
+func (e *StaleContentError) Error() string {
+	return fmt.Sprintf("returning stale content: %s", e.Err)
+}
+
+// Unwrap returns the error that caused the request to fail.
+func (e *StaleContentError) Unwrap() error {
+	return e.Err
+}
+
+// failed reports whether the error means no content was returned along with
+// it, unlike a StaleContentError.
+func failed(err error) bool {
+	var stale *StaleContentError
+	return err != nil && !errors.As(err, &stale)
+}
+
 // YearKeys is map of a string year to the string Yahoo uses to identify the
 // fantasy football game for that year.
 var YearKeys = map[string]string{
@@ -94,6 +150,25 @@ var YearKeys = map[string]string{
 type Client struct {
 	// Provides fantasy content for this application.
 	Provider ContentProvider
+	// Traces the requests made by this client, if set
+	Tracer Tracer
+	// Logs the requests made by this client, if set
+	Logger Logger
+	// Base URL of the API used by the convenience functions, e.g. to use a
+	// regional endpoint, proxy, or fake server. YahooBaseURL is used when
+	// empty. The resource types reported to metrics and traces are only known
+	// for base URLs with a path ending in "/fantasy/v2", like YahooBaseURL.
+	BaseURL string
+}
+
+// OAuthEndpoints are the URLs used to obtain an OAuth access token.
+type OAuthEndpoints struct {
+	// Creates OAuth request tokens
+	RequestTokenURL string
+	// Authorizes request tokens
+	AuthorizeTokenURL string
+	// Exchanges authorized request tokens for access tokens
+	AccessTokenURL string
 }
 
 // ContentProvider returns the data from an API request.
@@ -115,19 +190,132 @@ type Cache interface {
 	Get(url string, time time.Time) (content *FantasyContent, ok bool)
 }
 
+// StaleCache is a Cache that can also return content that is no longer valid
+// for the current time.
+type StaleCache interface {
+	Cache
+
+	// Gets the content for the URL that was valid for the period immediately
+	// before the given time, to be served in place of current content
+	GetStale(url string, time time.Time) (content *FantasyContent, ok bool)
+
+	// Gets the same content as GetStale without it being served, e.g. to
+	// check whether it has been modified
+	PeekStale(url string, time time.Time) (content *FantasyContent, ok bool)
+}
+
+// finalCache is a Cache that can keep content that will no longer change
+// without it expiring at the end of a cache period.
+type finalCache interface {
+	// Gets the content for the URL, only if it was cached along with its raw
+	// response when requireRaw is set
+	getFinal(url string, requireRaw bool) (*FantasyContent, bool)
+
+	// Sets the content for the URL
+	setFinal(url string, content *FantasyContent)
+}
+
+// CacheInspector is a Cache that reports how it has been used and allows its
+// entries to be examined and invalidated.
+type CacheInspector interface {
+	Cache
+
+	// Gets the usage statistics of the cache
+	Stats() CacheStats
+
+	// Gets all cached entries with URLs starting with the given prefix
+	Entries(prefix string) []CacheEntry
+
+	// Removes all cached entries with URLs starting with the given prefix and
+	// returns the amount of entries removed
+	Invalidate(prefix string) int
+}
+
+// UserCache is a Cache that can be partitioned between the users of an
+// application. Content returned by the fantasy sports API often depends on
+// the user making the request, for example the leagues returned for
+// "users;use_login=1", so a single cache shared by multiple users must keep
+// each user's content separate.
+type UserCache interface {
+	Cache
+
+	// Gets a view of the cache that only sets and gets content for the given
+	// user
+	ForUser(userID string) Cache
+}
+
+// CacheUsage counts the results of looking up content in a cache.
+type CacheUsage struct {
+	// Requests for content found in the cache
+	Hits int64
+	// Requests for content not found in the cache
+	Misses int64
+	// Requests for content cached during a previous period, which is served
+	// when new content has not been modified or can't be retrieved
+	Stale int64
+}
+
+// CacheStats describes the usage and current contents of a cache.
+type CacheStats struct {
+	CacheUsage
+
+	// Amount of entries removed to make room for new content. Only accurate
+	// when the backing lru.LRUCache is changed solely through the LRUCache
+	// and the views returned by its ForUser.
+	Evictions int64
+	// Amount of entries currently cached
+	Entries int64
+	// Total size of the entries currently cached, which is their estimated
+	// size in bytes for caches created with NewByteSizedLRUCache and their
+	// amount otherwise
+	Size int64
+	// Usage broken down by the type of resource requested, e.g. "league"
+	Resources map[string]CacheUsage
+}
+
+// CacheEntry is a single piece of fantasy content stored in a cache.
+type CacheEntry struct {
+	Key  string
+	URL  string
+	Time time.Time
+	// Whether the content will no longer change, in which case it is cached
+	// without expiring until it is evicted or invalidated and Time is zero
+	Final   bool
+	Size    int
+	Content *FantasyContent
+}
+
 // LRUCache implements Cache utilizing a LRU cache and unique keys to cache
 // content for up to a maximum duration.
 type LRUCache struct {
 	ClientID        string
+	UserID          string
 	Duration        time.Duration
 	DurationSeconds int64
 	Cache           *lru.LRUCache
+
+	// Whether values are sized by the estimated bytes of their content
+	sizeInBytes bool
+	stats       *lruCacheStats
+}
+
+// lruCacheStats tracks the usage of a LRUCache
+type lruCacheStats struct {
+	sync.Mutex
+	usage     CacheUsage
+	evictions int64
+	resources map[string]*CacheUsage
+
+	// Held while changing the backing cache, so that evictions can be
+	// inferred from the change in its length
+	changes sync.Mutex
 }
 
 // LRUCacheValue implements lru.Value to be able to store fantasy content in
 // a LRUCache
 type LRUCacheValue struct {
 	content *FantasyContent
+	size    int
 }
 
 // cachedContentProvider implements ContentProvider and caches data from
@@ -135,6 +323,22 @@ type LRUCacheValue struct {
 type cachedContentProvider struct {
 	delegate ContentProvider
 	cache    Cache
+	// Whether content from the previous cache period is returned in a
+	// StaleContentError when the delegate fails
+	staleOnError bool
+}
+
+// revalidatingContentProvider is a ContentProvider that can check whether
+// previously retrieved content has been modified without retrieving it again.
+type revalidatingContentProvider interface {
+	ContentProvider
+
+	// Gets the content for the URL, returning the given content when it has
+	// not been modified
+	revalidate(
+		url string,
+		content *FantasyContent,
+		t trace) (*FantasyContent, error)
 }
 
 // xmlContentProvider implements ContentProvider and translates XML responses
@@ -144,13 +348,37 @@ type xmlContentProvider struct {
 	client httpAPIClient
 }
 
+// jsonContentProvider implements ContentProvider and translates JSON
+// responses from an httpAPIClient into the appropriate data.
+type jsonContentProvider struct {
+	// Makes HTTP requests to the API
+	client httpAPIClient
+}
+
+// contentDecoder decodes fantasy content from response bodies in a single
+// format.
+type contentDecoder struct {
+	// Name of the span recording the decoding
+	span string
+	// Returns a decoder reading the XML elements of the response body
+	newDecoder func(body io.Reader) (*xml.Decoder, error)
+}
+
 // httpAPIClient defines methods needed to communicate with the Yahoo fantasy
 // sports API over HTTP
 type httpAPIClient interface {
 	// Makes HTTP request to the API
 	Get(url string) (response *http.Response, err error)
+	// Makes an arbitrary HTTP request to the API
+	Do(request *http.Request) (response *http.Response, err error)
 	// Get the amount of requests made to the API
 	RequestCount() int
+
+	// Makes HTTP request to the API, recording retries in the trace
+	getTraced(url string, t trace) (response *http.Response, err error)
+	// Makes an arbitrary HTTP request to the API, recording retries in the
+	// trace
+	doTraced(request *http.Request, t trace) (response *http.Response, err error)
 }
 
 // HTTPClient defines methods needed to communicated with a service over HTTP
@@ -159,10 +387,28 @@ type HTTPClient interface {
 	Get(url string) (response *http.Response, err error)
 }
 
-// countingHTTPApiClient implements httpAPIClient
+// HTTPRequestClient is a HTTPClient that can also send arbitrary HTTP
+// requests, such as http.Client. Clients implementing this interface allow
+// cached content to be revalidated using conditional requests instead of
+// being retrieved again once it expires.
+type HTTPRequestClient interface {
+	HTTPClient
+
+	// Sends a HTTP request
+	Do(request *http.Request) (response *http.Response, err error)
+}
+
+// countingHTTPApiClient implements httpAPIClient and is safe for concurrent
+// use.
 type countingHTTPApiClient struct {
 	client       HTTPClient
-	requestCount int
+	requestCount int64
+
+	// Retries failed requests, DefaultRetryPolicy is used when nil
+	retryPolicy *RetryPolicy
+	// Waits between retries until the context is done, sleepContext is used
+	// when nil
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
 //
@@ -172,228 +418,324 @@ type countingHTTPApiClient struct {
 // FantasyContent is the root level response containing the data from a request
 // to the fantasy sports API.
 type FantasyContent struct {
-	XMLName xml.Name `xml:"fantasy_content"`
-	League  League   `xml:"league"`
-	Team    Team     `xml:"team"`
-	Users   []User   `xml:"users>user"`
-	Players []Player `xml:"players>player"`
+	XMLName xml.Name `xml:"fantasy_content" json:"-"`
+	League  League   `xml:"league" json:"league"`
+	Team    Team     `xml:"team" json:"team"`
+	Users   []User   `xml:"users>user" json:"users"`
+	Players []Player `xml:"players>player" json:"players"`
+
+	// Validators from the response used to revalidate cached content
+	etag         string
+	lastModified string
+	// Response the content was decoded from, if kept
+	raw *RawResponse
 }
 
 // User contains the games a user is participating in
 type User struct {
-	Games []Game `xml:"games>game"`
+	Games []Game `xml:"games>game" json:"games"`
 }
 
 // Game represents a single year in the Yahoo fantasy football ecosystem. It consists
 // of zero or more leagues.
 type Game struct {
-	Leagues []League `xml:"leagues>league"`
+	Leagues []League `xml:"leagues>league" json:"leagues"`
 }
 
 // A League is a uniquely identifiable group of players and teams. The scoring system,
 // roster details, and other metadata can differ between leagues.
 type League struct {
-	LeagueKey   string     `xml:"league_key"`
-	LeagueID    uint64     `xml:"league_id"`
-	Name        string     `xml:"name"`
-	URL         string     `xml:"url"`
-	Players     []Player   `xml:"players>player"`
-	Teams       []Team     `xml:"teams>team"`
-	DraftStatus string     `xml:"draft_status"`
-	CurrentWeek int        `xml:"current_week"`
-	StartWeek   int        `xml:"start_week"`
-	EndWeek     int        `xml:"end_week"`
-	IsFinished  bool       `xml:"is_finished"`
-	Standings   []Team     `xml:"standings>teams>team"`
-	Scoreboard  Scoreboard `xml:"scoreboard"`
-	Settings    Settings   `xml:"settings"`
+	LeagueKey   string     `xml:"league_key" json:"league_key"`
+	LeagueID    uint64     `xml:"league_id" json:"league_id"`
+	Name        string     `xml:"name" json:"name"`
+	URL         string     `xml:"url" json:"url"`
+	Players     []Player   `xml:"players>player" json:"players"`
+	Teams       []Team     `xml:"teams>team" json:"teams"`
+	DraftStatus string     `xml:"draft_status" json:"draft_status"`
+	CurrentWeek int        `xml:"current_week" json:"current_week"`
+	StartWeek   int        `xml:"start_week" json:"start_week"`
+	EndWeek     int        `xml:"end_week" json:"end_week"`
+	IsFinished  bool       `xml:"is_finished" json:"is_finished"`
+	Standings   []Team     `xml:"standings>teams>team" json:"standings"`
+	Scoreboard  Scoreboard `xml:"scoreboard" json:"scoreboard"`
+	Settings    Settings   `xml:"settings" json:"settings"`
 }
 
 // A Team is a participant in exactly one league.
 type Team struct {
-	TeamKey               string        `xml:"team_key"`
-	TeamID                uint64        `xml:"team_id"`
-	Name                  string        `xml:"name"`
-	URL                   string        `xml:"url"`
-	TeamLogos             []TeamLogo    `xml:"team_logos>team_logo"`
-	IsOwnedByCurrentLogin bool          `xml:"is_owned_by_current_login"`
-	WavierPriority        int           `xml:"waiver_priority"`
-	NumberOfMoves         int           `xml:"number_of_moves"`
-	NumberOfTrades        int           `xml:"number_of_trades"`
-	Managers              []Manager     `xml:"managers>manager"`
-	Matchups              []Matchup     `xml:"matchups>matchup"`
-	Roster                Roster        `xml:"roster"`
-	TeamPoints            Points        `xml:"team_points"`
-	TeamProjectedPoints   Points        `xml:"team_projected_points"`
-	TeamStandings         TeamStandings `xml:"team_standings"`
-	TeamStats             WeekStats     `xml:"team_stats"`
-	Players               []Player      `xml:"players>player"`
+	TeamKey               string        `xml:"team_key" json:"team_key"`
+	TeamID                uint64        `xml:"team_id" json:"team_id"`
+	Name                  string        `xml:"name" json:"name"`
+	URL                   string        `xml:"url" json:"url"`
+	TeamLogos             []TeamLogo    `xml:"team_logos>team_logo" json:"team_logos"`
+	IsOwnedByCurrentLogin bool          `xml:"is_owned_by_current_login" json:"is_owned_by_current_login"`
+	WavierPriority        int           `xml:"waiver_priority" json:"waiver_priority"`
+	NumberOfMoves         int           `xml:"number_of_moves" json:"number_of_moves"`
+	NumberOfTrades        int           `xml:"number_of_trades" json:"number_of_trades"`
+	Managers              []Manager     `xml:"managers>manager" json:"managers"`
+	Matchups              []Matchup     `xml:"matchups>matchup" json:"matchups"`
+	Roster                Roster        `xml:"roster" json:"roster"`
+	TeamPoints            Points        `xml:"team_points" json:"team_points"`
+	TeamProjectedPoints   Points        `xml:"team_projected_points" json:"team_projected_points"`
+	TeamStandings         TeamStandings `xml:"team_standings" json:"team_standings"`
+	TeamStats             WeekStats     `xml:"team_stats" json:"team_stats"`
+	Players               []Player      `xml:"players>player" json:"players"`
 }
 
 // Settings describes how a league is configured
 type Settings struct {
-	DraftType        string `xml:"draft_type"`
-	ScoringType      string `xml:"scoring_type"`
-	UsesPlayoff      bool   `xml:"uses_playoff"`
-	PlayoffStartWeek int    `xml:"playoff_start_week"`
-	StatCategories   []Stat `xml:"stat_categories>stats>stat"`
+	DraftType        string `xml:"draft_type" json:"draft_type"`
+	ScoringType      string `xml:"scoring_type" json:"scoring_type"`
+	UsesPlayoff      bool   `xml:"uses_playoff" json:"uses_playoff"`
+	PlayoffStartWeek int    `xml:"playoff_start_week" json:"playoff_start_week"`
+	StatCategories   []Stat `xml:"stat_categories>stats>stat" json:"stat_categories"`
 }
 
 // Scoreboard represents the matchups that occurred for one or more weeks.
 type Scoreboard struct {
-	Weeks    string    `xml:"week"`
-	Matchups []Matchup `xml:"matchups>matchup"`
+	Weeks    string    `xml:"week" json:"week"`
+	Matchups []Matchup `xml:"matchups>matchup" json:"matchups"`
 }
 
 // A Roster is the set of players belonging to one team for a given week.
 type Roster struct {
-	CoverageType string   `xml:"coverage_type"`
-	Players      []Player `xml:"players>player"`
-	Week         int      `xml:"week"`
+	CoverageType string   `xml:"coverage_type" json:"coverage_type"`
+	Players      []Player `xml:"players>player" json:"players"`
+	Week         int      `xml:"week" json:"week"`
 }
 
 // A Matchup is a collection of teams paired against one another for a given
 // week.
 type Matchup struct {
-	Week  int    `xml:"week"`
-	Teams []Team `xml:"teams>team"`
+	Week  int    `xml:"week" json:"week"`
+	Teams []Team `xml:"teams>team" json:"teams"`
 }
 
 // A Manager is a user in change of a given team.
 type Manager struct {
-	ManagerID      uint64 `xml:"manager_id"`
-	Nickname       string `xml:"nickname"`
-	GUID           string `xml:"guid"`
-	IsCurrentLogin bool   `xml:"is_current_login"`
+	ManagerID      uint64 `xml:"manager_id" json:"manager_id"`
+	Nickname       string `xml:"nickname" json:"nickname"`
+	GUID           string `xml:"guid" json:"guid"`
+	IsCurrentLogin bool   `xml:"is_current_login" json:"is_current_login"`
 }
 
 // Points represents scoring statistics for a time period specified by
 // CoverageType.
 type Points struct {
-	CoverageType string `xml:"coverage_type"`
-	Season       string `xml:"season"`
-	Week         int    `xml:"week"`
-	Total        float64
-	TotalStr     string `xml:"total"`
+	CoverageType string  `xml:"coverage_type" json:"coverage_type"`
+	Season       string  `xml:"season" json:"season"`
+	Week         int     `xml:"week" json:"week"`
+	Total        float64 `xml:"-" json:"total"`
+	TotalStr     string  `xml:"total" json:"-"`
 }
 
 // WeekStats is the set of stats for a given week.
 type WeekStats struct {
-	CoverageType string `xml:"coverage_type"`
-	Week         int    `xml:"week"`
-	Stats        []Stat `xml:"stats>stat"`
+	CoverageType string `xml:"coverage_type" json:"coverage_type"`
+	Week         int    `xml:"week" json:"week"`
+	Stats        []Stat `xml:"stats>stat" json:"stats"`
 }
 
+// SeasonStats is the set of stats for the time period specified by
+// CoverageType, either a season or a single week.
 type SeasonStats struct {
-	CoverageType string `xml:"coverage_type"`
-	Season       string `xml:"season"`
-	Stats        []Stat `xml:"stats>stat"`
+	CoverageType string `xml:"coverage_type" json:"coverage_type"`
+	Season       string `xml:"season" json:"season"`
+	Week         int    `xml:"week" json:"week"`
+	Stats        []Stat `xml:"stats>stat" json:"stats"`
 }
 
 // Stat represents scoring statistics for a single statistic category.
 type Stat struct {
-	StatId            int    `xml:"stat_id"`
-	Enabled           bool   `xml:"enabled"`
-	Name              string `xml:"name"`
-	DisplayName       string `xml:"display_name"`
-	IsOnlyDisplayStat bool   `xml:"is_only_display_stat"`
-	Value             string `xml:"value"`
+	StatId            int    `xml:"stat_id" json:"stat_id"`
+	Enabled           bool   `xml:"enabled" json:"enabled"`
+	Name              string `xml:"name" json:"name"`
+	DisplayName       string `xml:"display_name" json:"display_name"`
+	IsOnlyDisplayStat bool   `xml:"is_only_display_stat" json:"is_only_display_stat"`
+	Value             string `xml:"value" json:"value"`
 }
 
 // Record is the number of wins, losses, and ties for a given team in their
 // league.
 type Record struct {
-	Wins   int `xml:"wins"`
-	Losses int `xml:"losses"`
-	Ties   int `xml:"ties"`
+	Wins   int `xml:"wins" json:"wins"`
+	Losses int `xml:"losses" json:"losses"`
+	Ties   int `xml:"ties" json:"ties"`
 }
 
 // TeamStandings describes how a single Team ranks in their league.
 type TeamStandings struct {
-	Rank          int
-	RankStr       string  `xml:"rank"`
-	Record        Record  `xml:"outcome_totals"`
-	PointsFor     float64 `xml:"points_for"`
-	PointsAgainst float64 `xml:"points_against"`
+	Rank          int     `xml:"-" json:"rank"`
+	RankStr       string  `xml:"rank" json:"-"`
+	Record        Record  `xml:"outcome_totals" json:"outcome_totals"`
+	PointsFor     float64 `xml:"points_for" json:"points_for"`
+	PointsAgainst float64 `xml:"points_against" json:"points_against"`
 }
 
 // TeamLogo is a image for a given team.
 type TeamLogo struct {
-	Size string `xml:"size"`
-	URL  string `xml:"url"`
+	Size string `xml:"size" json:"size"`
+	URL  string `xml:"url" json:"url"`
 }
 
 // A Player is a single player for the given sport.
 type Player struct {
-	PlayerKey          string           `xml:"player_key"`
-	PlayerID           uint64           `xml:"player_id"`
-	Name               Name             `xml:"name"`
-	DisplayPosition    string           `xml:"display_position"`
-	ElligiblePositions []string         `xml:"elligible_positions>position"`
-	SelectedPosition   SelectedPosition `xml:"selected_position"`
-	PlayerPoints       Points           `xml:"player_points"`
-	EditorialTeamAbbr  string           `xml:"editorial_team_abbr"`
-	PlayerStats        SeasonStats      `xml:"player_stats"`
-	Status             string           `xml:"status"`
+	PlayerKey             string           `xml:"player_key" json:"player_key"`
+	PlayerID              uint64           `xml:"player_id" json:"player_id"`
+	Name                  Name             `xml:"name" json:"name"`
+	DisplayPosition       string           `xml:"display_position" json:"display_position"`
+	ElligiblePositions    []string         `xml:"eligible_positions>position" json:"eligible_positions"`
+	SelectedPosition      SelectedPosition `xml:"selected_position" json:"selected_position"`
+	PlayerPoints          Points           `xml:"player_points" json:"player_points"`
+	EditorialTeamAbbr     string           `xml:"editorial_team_abbr" json:"editorial_team_abbr"`
+	PlayerStats           SeasonStats      `xml:"player_stats" json:"player_stats"`
+	Status                string           `xml:"status" json:"status"`
+	StatusFull            string           `xml:"status_full" json:"status_full"`
+	InjuryNote            string           `xml:"injury_note" json:"injury_note"`
+	EditorialTeamKey      string           `xml:"editorial_team_key" json:"editorial_team_key"`
+	EditorialTeamFullName string           `xml:"editorial_team_full_name" json:"editorial_team_full_name"`
+	ByeWeeks              []int            `xml:"bye_weeks>week" json:"bye_weeks"`
+	UniformNumber         string           `xml:"uniform_number" json:"uniform_number"`
+	Headshot              Headshot         `xml:"headshot" json:"headshot"`
+	ImageURL              string           `xml:"image_url" json:"image_url"`
+	IsUndroppable         bool             `xml:"is_undroppable" json:"is_undroppable"`
+	PositionType          string           `xml:"position_type" json:"position_type"`
+	PercentOwned          Percent          `xml:"percent_owned" json:"percent_owned"`
+	PercentStarted        Percent          `xml:"percent_started" json:"percent_started"`
+	Ownership             Ownership        `xml:"ownership" json:"ownership"`
+}
+
+// Headshot is an image of a Player.
+type Headshot struct {
+	URL  string `xml:"url" json:"url"`
+	Size string `xml:"size" json:"size"`
+}
+
+// Percent is the percentage of leagues in which a Player is owned or started
+// for the time period specified by CoverageType, and its change from the
+// previous period.
+type Percent struct {
+	CoverageType string  `xml:"coverage_type" json:"coverage_type"`
+	Week         int     `xml:"week" json:"week"`
+	Date         string  `xml:"date" json:"date"`
+	Value        float64 `xml:"value" json:"value"`
+	Delta        float64 `xml:"delta" json:"delta"`
+}
+
+// Ownership describes who owns a Player in a league. OwnershipType is "team"
+// for players on a team, with the key and name of the team, or "freeagents"
+// or "waivers" otherwise.
+type Ownership struct {
+	OwnershipType string `xml:"ownership_type" json:"ownership_type"`
+	OwnerTeamKey  string `xml:"owner_team_key" json:"owner_team_key"`
+	OwnerTeamName string `xml:"owner_team_name" json:"owner_team_name"`
+	WaiverDate    string `xml:"waiver_date" json:"waiver_date"`
+}
+
+// PlayerWeekStats is the points and stats of a Player for a single week.
+type PlayerWeekStats struct {
+	Week   int    `xml:"week" json:"week"`
+	Points Points `xml:"player_points" json:"points"`
+	Stats  []Stat `xml:"stats>stat" json:"stats"`
 }
 
 // SelectedPosition is the position chosen for a Player for a given week.
 type SelectedPosition struct {
-	CoverageType string `xml:"coverage_type"`
-	Week         int    `xml:"week"`
-	Position     string `xml:"position"`
+	CoverageType string `xml:"coverage_type" json:"coverage_type"`
+	Week         int    `xml:"week" json:"week"`
+	Position     string `xml:"position" json:"position"`
 }
 
 // Name is a name of a player.
 type Name struct {
-	Full  string `xml:"full"`
-	First string `xml:"first"`
-	Last  string `xml:"last"`
+	Full  string `xml:"full" json:"full"`
+	First string `xml:"first" json:"first"`
+	Last  string `xml:"last" json:"last"`
 }
 
 //
 // Client
 
+// NewCachedUserClient creates a new fantasy client that checks and updates
+// the given Cache when retrieving fantasy content, only sharing cached content
+// with clients created for the same user. The user ID should uniquely
+// identify the user that authorized the given HTTPClient, such as the Yahoo
+// GUID returned as "xoauth_yahoo_guid" in the additional data of the
+// oauth.AccessToken.
+//
+// See NewLRUCache
+func NewCachedUserClient(userID string, cache UserCache, client HTTPClient) *Client {
+	return New(client, WithUserCache(userID, cache))
+}
+
 // NewCachedClient creates a new fantasy client that checks and updates the
 // given Cache when retrieving fantasy content.
 //
 // See NewLRUCache
 func NewCachedClient(cache Cache, client HTTPClient) *Client {
-	return &Client{
-		Provider: &cachedContentProvider{
-			delegate: NewClient(client).Provider,
-			cache:    cache,
-		},
-	}
+	return New(client, WithCache(cache))
+}
+
+// NewCachedFormatClient creates a new fantasy client that requests content in
+// the given format, checking and updating the given Cache when retrieving it.
+//
+// See NewLRUCache
+func NewCachedFormatClient(
+	cache Cache,
+	client HTTPClient,
+	format Format) *Client {
+
+	return New(client, WithCache(cache), WithFormat(format))
 }
 
 // NewClient creates a Client that to communicate with the Yahoo fantasy
 // sports API. See the package level documentation for one way to create a
 // http.Client that can authenticate with Yahoo's APIs which can be passed
 // in here.
+//
+// See New to configure the client with options.
 func NewClient(c HTTPClient) *Client {
-	return &Client{
-		Provider: &xmlContentProvider{
-			client: &countingHTTPApiClient{
-				client:       c,
-				requestCount: 0,
-			},
-		},
-	}
+	return New(c)
+}
+
+// NewFormatClient creates a Client that requests content from the Yahoo
+// fantasy sports API in the given format.
+//
+// See NewClient
+func NewFormatClient(c HTTPClient, format Format) *Client {
+	return New(c, WithFormat(format))
 }
 
 // GetConsumer generates an OAuth Consumer for the Yahoo fantasy sports API
 func GetConsumer(clientID string, clientSecret string) *oauth.Consumer {
+	return GetEndpointConsumer(clientID, clientSecret, YahooOAuthEndpoints)
+}
+
+// GetEndpointConsumer generates an OAuth Consumer for the Yahoo fantasy
+// sports API that obtains access tokens from the given endpoints.
+func GetEndpointConsumer(
+	clientID string,
+	clientSecret string,
+	endpoints OAuthEndpoints) *oauth.Consumer {
+
 	return oauth.NewConsumer(
 		clientID,
 		clientSecret,
 		oauth.ServiceProvider{
-			RequestTokenUrl:   YahooRequestTokenURL,
-			AuthorizeTokenUrl: YahooAuthTokenURL,
-			AccessTokenUrl:    YahooGetTokenURL,
+			RequestTokenUrl:   endpoints.RequestTokenURL,
+			AuthorizeTokenUrl: endpoints.AuthorizeTokenURL,
+			AccessTokenUrl:    endpoints.AccessTokenURL,
 		})
 }
 
+// URL returns a URLBuilder for URLs relative to the client's BaseURL.
+func (c *Client) URL() URLBuilder {
+	if c.BaseURL == "" {
+		return NewURLBuilder(YahooBaseURL)
+	}
+	return NewURLBuilder(c.BaseURL)
+}
+
 // RequestCount returns the amount of requests made to the Yahoo API on behalf
 // of the application represented by this Client.
 func (c *Client) RequestCount() int {
@@ -407,6 +749,9 @@ func (c *Client) RequestCount() int {
 // NewLRUCache creates a new Cache that caches content for the given client
 // for up to the maximum duration.
 //
+// The capacity of the given lru.LRUCache is measured in the amount of cached
+// content, see NewByteSizedLRUCache to measure it in bytes instead.
+//
 // See NewCachedClient
 func NewLRUCache(
 	clientID string,
@@ -418,31 +763,257 @@ func NewLRUCache(
 		Duration:        duration,
 		DurationSeconds: int64(duration.Seconds()),
 		Cache:           cache,
+		stats: &lruCacheStats{
+			resources: make(map[string]*CacheUsage),
+		},
 	}
 }
 
+// NewByteSizedLRUCache creates a new Cache that caches content for the given
+// client for up to the maximum duration.
+//
+// The capacity of the given lru.LRUCache is measured in bytes, using the
+// estimated in-memory size of the cached content. For example, to limit the
+// cache to roughly 64MB:
+//
+//    goff.NewByteSizedLRUCache(clientID, time.Hour,
+//        lru.NewLRUCache(64*1024*1024))
+//
+// See NewLRUCache
+func NewByteSizedLRUCache(
+	clientID string,
+	duration time.Duration,
+	cache *lru.LRUCache) *LRUCache {
+
+	l := NewLRUCache(clientID, duration, cache)
+	l.sizeInBytes = true
+	return l
+}
+
 // Set specifies that the given content was retrieved for the given URL at the
 // given time. The content for that URL will be available by LRUCache.Get from
 // the given 'time' up to 'time + l.Duration'
 func (l *LRUCache) Set(url string, time time.Time, content *FantasyContent) {
-	l.Cache.Set(l.getKey(url, time), &LRUCacheValue{content: content})
+	l.set(l.getKey(url, time), content)
+}
+
+// setFinal specifies that the given content, which will no longer change, was
+// retrieved for the given URL. It is available by LRUCache.getFinal until it
+// is evicted or invalidated.
+func (l *LRUCache) setFinal(url string, content *FantasyContent) {
+	l.set(l.getFinalKey(url), content)
+}
+
+// set stores the content with the given key, counting the entries evicted to
+// make room for it.
+func (l *LRUCache) set(key string, content *FantasyContent) {
+	value := newLRUCacheValue(content, l.sizeInBytes)
+	if l.stats == nil {
+		l.Cache.Set(key, value)
+		return
+	}
+
+	// The backing cache evicts silently, so infer the amount of evicted
+	// entries from the change in its length.
+	l.stats.changes.Lock()
+	_, replaced := l.Cache.Peek(key)
+	before := l.Cache.Length()
+	l.Cache.Set(key, value)
+	evicted := before - l.Cache.Length()
+	l.stats.changes.Unlock()
+
+	if !replaced {
+		evicted++
+	}
+	if evicted > 0 {
+		l.stats.Lock()
+		l.stats.evictions += evicted
+		l.stats.Unlock()
+	}
 }
 
 // Get the content for the given URL at the given time.
 func (l *LRUCache) Get(url string, time time.Time) (content *FantasyContent, ok bool) {
-	value, ok := l.Cache.Get(l.getKey(url, time))
+	return l.get(url, l.getKey(url, time), false)
+}
+
+// getRaw gets the content for the given URL only if it was cached along with
+// its raw response, counting content cached without one as a miss.
+func (l *LRUCache) getRaw(url string, time time.Time) (*FantasyContent, bool) {
+	return l.get(url, l.getKey(url, time), true)
+}
+
+// getFinal gets the content for the given URL that was cached by
+// LRUCache.setFinal, regardless of the current time.
+func (l *LRUCache) getFinal(url string, requireRaw bool) (*FantasyContent, bool) {
+	return l.get(url, l.getFinalKey(url), requireRaw)
+}
+
+// get looks up the content for the given URL stored with the given key and
+// records the result in the usage of the cache.
+func (l *LRUCache) get(
+	url string,
+	key string,
+	requireRaw bool) (content *FantasyContent, ok bool) {
+
+	content, ok = l.lookup(key)
+	if ok && requireRaw && content.raw == nil {
+		content, ok = nil, false
+	}
+	l.record(url, func(u *CacheUsage) {
+		if ok {
+			u.Hits++
+		} else {
+			u.Misses++
+		}
+	})
+	return content, ok
+}
+
+// GetStale gets the content for the given URL that was cached during the
+// period immediately before the given time, counting it as stale content
+// served by the cache.
+func (l *LRUCache) GetStale(url string, time time.Time) (content *FantasyContent, ok bool) {
+	content, ok = l.PeekStale(url, time)
+	if ok {
+		l.record(url, func(u *CacheUsage) { u.Stale++ })
+	}
+	return content, ok
+}
+
+// PeekStale gets the content for the given URL that was cached during the
+// period immediately before the given time, without updating the usage of
+// the cache.
+func (l *LRUCache) PeekStale(url string, time time.Time) (content *FantasyContent, ok bool) {
+	return l.lookup(l.getKey(url, time.Add(-l.Duration)))
+}
+
+// ForUser returns a LRUCache sharing the same backing lru.LRUCache and usage
+// statistics as this cache, but that only sets and gets content for the given
+// user.
+//
+// See NewCachedUserClient
+func (l *LRUCache) ForUser(userID string) Cache {
+	userCache := *l
+	userCache.UserID = userID
+	return &userCache
+}
+
+// Stats returns the usage of this cache since it was created along with the
+// amount and size of the entries it currently holds. Like the usage, which is
+// shared with the views returned by ForUser, the entries include those cached
+// for every user of the client, but not those cached for other clients sharing
+// the backing lru.LRUCache.
+func (l *LRUCache) Stats() CacheStats {
+	stats := CacheStats{Resources: make(map[string]CacheUsage)}
+	for _, item := range l.Cache.Items() {
+		if _, _, _, _, ok := l.parseKey(item.Key); !ok {
+			continue
+		}
+		stats.Entries++
+		stats.Size += int64(item.Value.Size())
+	}
+	if l.stats == nil {
+		return stats
+	}
+
+	l.stats.Lock()
+	defer l.stats.Unlock()
+	stats.CacheUsage = l.stats.usage
+	stats.Evictions = l.stats.evictions
+	for resource, usage := range l.stats.resources {
+		stats.Resources[resource] = *usage
+	}
+	return stats
+}
+
+// Entries returns the content cached by this client and user for all URLs
+// beginning with the given prefix. For example, using the prefix
+// "<YahooBaseURL>/league/<league-key>" returns every cached request made for
+// that league.
+func (l *LRUCache) Entries(prefix string) []CacheEntry {
+	entries := make([]CacheEntry, 0)
+	for _, item := range l.Cache.Items() {
+		userID, url, period, final, ok := l.parseKey(item.Key)
+		if !ok || userID != l.UserID || !strings.HasPrefix(url, prefix) {
+			continue
+		}
+		value, ok := item.Value.(*LRUCacheValue)
+		if !ok {
+			continue
+		}
+		entry := CacheEntry{
+			Key:     item.Key,
+			URL:     url,
+			Final:   final,
+			Size:    value.Size(),
+			Content: value.content,
+		}
+		if !final {
+			entry.Time = time.Unix(period*l.DurationSeconds, 0)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Invalidate removes the content cached by this client for all URLs beginning
+// with the given prefix and returns the amount of entries removed. Content
+// cached for every user of the client is removed, regardless of the user of
+// this cache.
+func (l *LRUCache) Invalidate(prefix string) int {
+	if l.stats != nil {
+		l.stats.changes.Lock()
+		defer l.stats.changes.Unlock()
+	}
+
+	removed := 0
+	for _, item := range l.Cache.Items() {
+		_, url, _, _, ok := l.parseKey(item.Key)
+		if !ok || !strings.HasPrefix(url, prefix) {
+			continue
+		}
+		if l.Cache.Delete(item.Key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// lookup returns the fantasy content stored with the given key.
+func (l *LRUCache) lookup(key string) (*FantasyContent, bool) {
+	value, ok := l.Cache.Get(key)
 	if !ok {
-		return nil, ok
+		return nil, false
 	}
 	lruCacheValue, ok := value.(*LRUCacheValue)
 	if !ok {
-		return nil, ok
+		return nil, false
 	}
 	return lruCacheValue.content, true
 }
 
-// getKey converts a base key to a key that is unique for the client of the
-// LRUCache and the current time period.
+// record updates the overall usage of the cache as well as the usage for the
+// type of resource requested by the given URL.
+func (l *LRUCache) record(url string, update func(u *CacheUsage)) {
+	if l.stats == nil {
+		return
+	}
+
+	resource := ResourceType(url)
+	l.stats.Lock()
+	defer l.stats.Unlock()
+	usage, ok := l.stats.resources[resource]
+	if !ok {
+		usage = &CacheUsage{}
+		l.stats.resources[resource] = usage
+	}
+	update(&l.stats.usage)
+	update(usage)
+}
+
+// getKey converts a base key to a key that is unique for the client and user
+// of the LRUCache and the current time period.
 //
 // The created keys have the following format:
 //
@@ -454,16 +1025,176 @@ func (l *LRUCache) Get(url string, time time.Time) (content *FantasyContent, ok
 //
 //    client-id-01:key-01:391189
 //
+// When the cache has a user ID, such as "user-01", it is included with the
+// client ID:
+//
+//    client-id-01@user-01:key-01:391189
+//
+// The client and user IDs are escaped, so neither can contain the "@" or ":"
+// separating them from the rest of the key.
 func (l *LRUCache) getKey(originalKey string, time time.Time) string {
 	period := time.Unix() / l.DurationSeconds
-	return fmt.Sprintf("%s:%s:%d", l.ClientID, originalKey, period)
+	return fmt.Sprintf("%s:%s:%d", l.scope(), originalKey, period)
 }
 
-// Size always returns '1'. All LRU cache values have the same size, meaning
-// the backing lru.LRUCache will prune strictly based on number of cached
-// content and not the total size in memory.
+// finalPeriod replaces the period in the keys of content that will no longer
+// change.
+const finalPeriod = "final"
+
+// getFinalKey converts a base key to a key that is unique for the client and
+// user of the LRUCache, regardless of the time, for content that will no
+// longer change. Given the client ID and original key of the example for
+// LRUCache.getKey, this will generate the following key:
+//
+//    client-id-01:key-01:final
+func (l *LRUCache) getFinalKey(originalKey string) string {
+	return fmt.Sprintf("%s:%s:%s", l.scope(), originalKey, finalPeriod)
+}
+
+// parseKey reverses getKey and getFinalKey, returning the user, original key,
+// and period of a key created for the client of this LRUCache and any of its
+// users, and whether it is the key of final content without a period.
+func (l *LRUCache) parseKey(key string) (
+	userID string,
+	originalKey string,
+	period int64,
+	final bool,
+	ok bool) {
+
+	index := strings.Index(key, ":")
+	if index < 0 {
+		return "", "", 0, false, false
+	}
+	clientID, userID, ok := parseScope(key[:index])
+	if !ok || clientID != l.ClientID {
+		return "", "", 0, false, false
+	}
+	key = key[index+1:]
+
+	index = strings.LastIndex(key, ":")
+	if index < 0 {
+		return "", "", 0, false, false
+	}
+	if key[index+1:] == finalPeriod {
+		return userID, key[:index], 0, true, true
+	}
+	period, err := strconv.ParseInt(key[index+1:], 10, 64)
+	if err != nil {
+		return "", "", 0, false, false
+	}
+	return userID, key[:index], period, false, true
+}
+
+// scope returns the part of a key identifying the client and user the
+// content was cached for.
+func (l *LRUCache) scope() string {
+	if l.UserID == "" {
+		return url.QueryEscape(l.ClientID)
+	}
+	return url.QueryEscape(l.ClientID) + "@" + url.QueryEscape(l.UserID)
+}
+
+// parseScope reverses LRUCache.scope, returning the client and user IDs.
+func parseScope(scope string) (clientID string, userID string, ok bool) {
+	parts := strings.SplitN(scope, "@", 2)
+	clientID, err := url.QueryUnescape(parts[0])
+	if err != nil {
+		return "", "", false
+	}
+	if len(parts) == 1 {
+		return clientID, "", true
+	}
+	userID, err = url.QueryUnescape(parts[1])
+	if err != nil || userID == "" {
+		return "", "", false
+	}
+	return clientID, userID, true
+}
+
+// ResourceType returns the type of resource or collection requested by a
+// fantasy sports API URL, e.g. "league", "team", or "users". URLs outside of
+// the API are reported as "other".
+func ResourceType(url string) string {
+	const apiPath = "/fantasy/v2/"
+	index := strings.Index(url, apiPath)
+	if index < 0 {
+		return "other"
+	}
+	resource := url[index+len(apiPath):]
+	if end := strings.IndexAny(resource, "/;?"); end >= 0 {
+		resource = resource[:end]
+	}
+	if resource == "" {
+		return "other"
+	}
+	return resource
+}
+
+// newLRUCacheValue creates a LRUCacheValue for the given content, estimating
+// its size in bytes once up front if requested.
+func newLRUCacheValue(content *FantasyContent, sizeInBytes bool) *LRUCacheValue {
+	value := &LRUCacheValue{content: content}
+	if sizeInBytes {
+		value.size = contentSize(content)
+	}
+	return value
+}
+
+// Size returns '1' unless the value was cached by a LRUCache created with
+// NewByteSizedLRUCache, meaning the backing lru.LRUCache will prune strictly
+// based on number of cached content and not the total size in memory.
+// Otherwise, the estimated size in bytes of the cached content is returned.
 func (v *LRUCacheValue) Size() int {
-	return 1
+	if v.size < 1 {
+		return 1
+	}
+	return v.size
+}
+
+// contentSize estimates the amount of memory used by the given content by
+// walking its structure.
+func contentSize(content *FantasyContent) int {
+	if content == nil {
+		return 0
+	}
+	value := reflect.ValueOf(content).Elem()
+	return int(value.Type().Size()) + referencedSize(value)
+}
+
+// referencedSize estimates the amount of memory referenced by, but not stored
+// directly in, the given value. This includes the bytes of strings, the
+// backing arrays of slices, and the entries of maps.
+func referencedSize(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len()
+	case reflect.Slice:
+		size := v.Cap() * int(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i))
+		}
+		return size
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i))
+		}
+		return size
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0
+		}
+		return int(v.Elem().Type().Size()) + referencedSize(v.Elem())
+	case reflect.Map:
+		size := 0
+		for _, key := range v.MapKeys() {
+			size += int(key.Type().Size()) + referencedSize(key)
+			value := v.MapIndex(key)
+			size += int(value.Type().Size()) + referencedSize(value)
+		}
+		return size
+	}
+	return 0
 }
 
 //
@@ -471,81 +1202,340 @@ func (v *LRUCacheValue) Size() int {
 //
 
 func (p *cachedContentProvider) Get(url string) (*FantasyContent, error) {
+	return p.get(url, trace{})
+}
+
+// get returns the cached content for the URL, or gets it from the delegate
+// provider, recording the cache lookup in the trace.
+func (p *cachedContentProvider) get(url string, t trace) (*FantasyContent, error) {
+	if cache, ok := p.cache.(finalCache); ok && t.final {
+		return p.getFinal(url, cache, t)
+	}
+
 	currentTime := time.Now()
-	content, ok := p.cache.Get(url, currentTime)
-	if !ok {
-		content, err := p.delegate.Get(url)
-		if err == nil {
-			p.cache.Set(url, currentTime, content)
+	lookup := t.start("goff.cache.lookup")
+	content, ok := p.lookup(url, currentTime, t.keepRaw)
+	lookup.set(attributeCacheHit, ok)
+	lookup.end(nil)
+	t.setRoot(attributeCacheHit, ok)
+	if ok {
+		return content, nil
+	}
+
+	stale, hasStale := p.peekStale(url, currentTime)
+	if hasStale && t.keepRaw && stale.raw == nil {
+		hasStale = false
+	}
+	revalidator, canRevalidate := p.delegate.(revalidatingContentProvider)
+	var err error
+	if hasStale && canRevalidate {
+		content, err = revalidator.revalidate(url, stale, t)
+	} else {
+		content, err = getTraced(p.delegate, url, t)
+	}
+
+	if err == nil {
+		// Stale content is only returned again if it has not been modified
+		if hasStale && content == stale {
+			p.serveStale(url, currentTime)
 		}
-		return content, err
+		p.cache.Set(url, currentTime, content)
+		return content, nil
+	}
+
+	// Errors caused by a lack of permission never return stale content.
+	if p.staleOnError && hasStale && err != ErrAccessDenied {
+		p.serveStale(url, currentTime)
+		t.setRoot(attributeCacheStale, true)
+		return stale, &StaleContentError{Content: stale, Err: err}
+	}
+	return content, err
+}
+
+// getFinal returns content that will no longer change for the URL from the
+// cache, or gets it from the delegate provider and caches it without expiring.
+func (p *cachedContentProvider) getFinal(
+	url string,
+	cache finalCache,
+	t trace) (*FantasyContent, error) {
+
+	lookup := t.start("goff.cache.lookup")
+	content, ok := cache.getFinal(url, t.keepRaw)
+	lookup.set(attributeCacheHit, ok)
+	lookup.end(nil)
+	t.setRoot(attributeCacheHit, ok)
+	if ok {
+		return content, nil
+	}
+
+	content, err := getTraced(p.delegate, url, t)
+	if err != nil {
+		return nil, err
 	}
+	cache.setFinal(url, content)
 	return content, nil
 }
 
+// lookup returns the content cached for the URL. When keepRaw is set, content
+// cached without its raw response is not returned.
+func (p *cachedContentProvider) lookup(
+	url string,
+	currentTime time.Time,
+	keepRaw bool) (*FantasyContent, bool) {
+
+	if !keepRaw {
+		return p.cache.Get(url, currentTime)
+	}
+	if cache, ok := p.cache.(rawCache); ok {
+		return cache.getRaw(url, currentTime)
+	}
+	content, ok := p.cache.Get(url, currentTime)
+	if !ok || content.raw == nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// peekStale returns content cached for the URL during the previous cache
+// period, if available, without it counting as served by the cache.
+func (p *cachedContentProvider) peekStale(
+	url string,
+	currentTime time.Time) (*FantasyContent, bool) {
+
+	staleCache, ok := p.cache.(StaleCache)
+	if !ok {
+		return nil, false
+	}
+	return staleCache.PeekStale(url, currentTime)
+}
+
+// serveStale records that content cached for the URL during the previous
+// cache period is being served.
+func (p *cachedContentProvider) serveStale(url string, currentTime time.Time) {
+	if staleCache, ok := p.cache.(StaleCache); ok {
+		staleCache.GetStale(url, currentTime)
+	}
+}
+
 func (p *cachedContentProvider) RequestCount() int {
 	return p.delegate.RequestCount()
 }
 
+// xmlDecoder unmarshals the XML responses of the API
+var xmlDecoder = contentDecoder{
+	span: "goff.xml.decode",
+	newDecoder: func(body io.Reader) (*xml.Decoder, error) {
+		return xml.NewDecoder(body), nil
+	},
+}
+
 func (p *xmlContentProvider) Get(url string) (*FantasyContent, error) {
-	response, err := p.client.Get(url)
+	return p.get(url, trace{})
+}
+
+// get requests the content for the URL, recording the request and the
+// decoding of its response in the trace.
+func (p *xmlContentProvider) get(url string, t trace) (*FantasyContent, error) {
+	return getContent(p.client, url, xmlDecoder, t)
+}
+
+// revalidate makes a conditional request for the given URL using the
+// validators of previously retrieved content. If the API responds that the
+// content has not been modified, the previous content is returned.
+func (p *xmlContentProvider) revalidate(
+	url string,
+	content *FantasyContent,
+	t trace) (*FantasyContent, error) {
+
+	return revalidateContent(p.client, url, content, xmlDecoder, t)
+}
 
+// getContent requests the content for the URL using the client and decodes
+// the response, recording both in the trace.
+func getContent(
+	client httpAPIClient,
+	url string,
+	decoder contentDecoder,
+	t trace) (*FantasyContent, error) {
+
+	response, err := fetchContent(client, url, t)
 	if err != nil {
 		return nil, err
 	}
 	defer response.Body.Close()
+	return readContent(response, decoder, t)
+}
+
+// fetchContent requests the content for the URL using the client, recording
+// the request in the trace.
+func fetchContent(
+	client httpAPIClient,
+	url string,
+	t trace) (*http.Response, error) {
+
+	fetch := t.start("goff.http.fetch")
+	response, err := client.getTraced(url, t)
+	if response != nil {
+		fetch.set(attributeStatusCode, response.StatusCode)
+	}
+	fetch.end(err)
 
-	bits, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return nil, err
 	}
+	t.setRoot(attributeStatusCode, response.StatusCode)
+	return response, nil
+}
 
+// revalidateContent makes a conditional request for the URL using the
+// validators of the given content, returning that content when it has not
+// been modified and otherwise decoding the response.
+func revalidateContent(
+	client httpAPIClient,
+	url string,
+	content *FantasyContent,
+	decoder contentDecoder,
+	t trace) (*FantasyContent, error) {
+
+	if content.etag == "" && content.lastModified == "" {
+		return getContent(client, url, decoder, t)
+	}
+
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if content.etag != "" {
+		request.Header.Set("If-None-Match", content.etag)
+	}
+	if content.lastModified != "" {
+		request.Header.Set("If-Modified-Since", content.lastModified)
+	}
+
+	fetch := t.start("goff.http.fetch")
+	response, err := client.doTraced(request, t)
+	if response != nil {
+		fetch.set(attributeStatusCode, response.StatusCode)
+	}
+	fetch.end(err)
+
+	// Content is retrieved again by clients that can't make conditional
+	// requests.
+	if errors.Is(err, ErrRequestUnsupported) {
+		return getContent(client, url, decoder, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	t.setRoot(attributeStatusCode, response.StatusCode)
+	if response.StatusCode == http.StatusNotModified {
+		return content, nil
+	}
+	return readContent(response, decoder, t)
+}
+
+// readContent decodes the fantasy content directly from the body of the
+// response using the decoder, recording the decoding in the trace
+func readContent(
+	response *http.Response,
+	decoder contentDecoder,
+	t trace) (*FantasyContent, error) {
+
+	decode := t.start(decoder.span)
+	var raw bytes.Buffer
+	var reader io.Reader = response.Body
+	if t.keepRaw {
+		reader = io.TeeReader(response.Body, &raw)
+	}
+	body := &countingReader{reader: reader}
 	var content FantasyContent
-	err = xml.Unmarshal(bits, &content)
+	err := decodeContent(body, decoder, &content)
+	decode.set(attributeBytes, body.count)
+	t.setRoot(attributeBytes, body.count)
+	decode.end(err)
 	if err != nil {
 		return nil, err
 	}
+	content.etag = response.Header.Get("ETag")
+	content.lastModified = response.Header.Get("Last-Modified")
+	if t.keepRaw {
+		content.raw = newRawResponse(response, raw.Bytes())
+	}
 
+	fix := t.start("goff.fixContent")
+	defer fix.end(nil)
 	return fixContent(&content), nil
 }
 
 // fixContent updates the fantasy data with content that can't be unmarshalled
-// directly from XML
+// directly from XML. Every Points and TeamStandings reachable from the content
+// is fixed, regardless of how deeply it is nested or whether the response is
+// complete, so malformed content like a matchup without two teams is left
+// as-is rather than causing a panic.
 func fixContent(c *FantasyContent) *FantasyContent {
-	fixTeam(&c.Team)
-	for i := range c.League.Teams {
-		fixTeam(&c.League.Teams[i])
-	}
-	for i := range c.League.Standings {
-		fixTeam(&c.League.Standings[i])
-	}
-	for i := range c.League.Players {
-		fixPoints(&c.League.Players[i].PlayerPoints)
-	}
-	for i := range c.League.Scoreboard.Matchups {
-		fixTeam(&c.League.Scoreboard.Matchups[i].Teams[0])
-		fixTeam(&c.League.Scoreboard.Matchups[i].Teams[1])
+	if c != nil {
+		fixValue(reflect.ValueOf(c).Elem())
 	}
 	return c
 }
 
-func fixTeam(t *Team) {
-	fixPoints(&t.TeamPoints)
-	fixPoints(&t.TeamProjectedPoints)
-	for i := range t.Roster.Players {
-		fixPoints(&t.Roster.Players[i].PlayerPoints)
+// contentFixer is implemented by types with fields derived from the raw
+// content after unmarshalling.
+type contentFixer interface {
+	fix()
+}
+
+// fixValue fixes v and every exported value it refers to.
+func fixValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.CanAddr() {
+			if fixer, ok := v.Addr().Interface().(contentFixer); ok {
+				fixer.fix()
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanInterface() {
+				fixValue(field)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			fixValue(v.Index(i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			fixValue(v.Elem())
+		}
 	}
-	for i := range t.Players {
-		fixPoints(&t.Players[i].PlayerPoints)
+}
+
+// MarshalXML encodes the content as the root element of a response, keeping
+// the name and namespace it was decoded with.
+func (c FantasyContent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type xmlFantasyContent FantasyContent
+	if c.XMLName.Local == "" {
+		return e.Encode(xmlFantasyContent(c))
 	}
-	for i := range t.Matchups {
-		fixTeam(&t.Matchups[i].Teams[0])
-		fixTeam(&t.Matchups[i].Teams[1])
+	start.Name = c.XMLName
+	return e.EncodeElement(xmlFantasyContent(c), start)
+}
+
+// UnmarshalXML decodes the standings, converting the rank to an integer.
+func (t *TeamStandings) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type xmlTeamStandings TeamStandings
+	if err := d.DecodeElement((*xmlTeamStandings)(t), &start); err != nil {
+		return err
 	}
-	fixRank(&t.TeamStandings)
+	t.fix()
+	return nil
 }
 
-func fixRank(t *TeamStandings) {
+// fix sets Rank from RankStr, which is empty for leagues whose standings
+// haven't been determined.
+func (t *TeamStandings) fix() {
 	if t.RankStr != "" {
 		rank, err := strconv.ParseInt(t.RankStr, 10, 64)
 		if err == nil {
@@ -554,7 +1544,30 @@ func fixRank(t *TeamStandings) {
 	}
 }
 
-func fixPoints(p *Points) {
+// MarshalXML encodes the standings with the rank as it appears in a response.
+// RankStr is used unless Rank has been changed to a different number.
+func (t TeamStandings) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type xmlTeamStandings TeamStandings
+	rank, err := strconv.ParseInt(t.RankStr, 10, 64)
+	if t.Rank != 0 && (err != nil || int(rank) != t.Rank) {
+		t.RankStr = strconv.Itoa(t.Rank)
+	}
+	return e.EncodeElement(xmlTeamStandings(t), start)
+}
+
+// UnmarshalXML decodes the points, converting the total to a float.
+func (p *Points) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type xmlPoints Points
+	if err := d.DecodeElement((*xmlPoints)(p), &start); err != nil {
+		return err
+	}
+	p.fix()
+	return nil
+}
+
+// fix sets Total from TotalStr, leaving it unchanged when the total is
+// missing or isn't a number.
+func (p *Points) fix() {
 	if p.TotalStr != "" {
 		total, err := strconv.ParseFloat(p.TotalStr, 64)
 		if err == nil {
@@ -563,6 +1576,19 @@ func fixPoints(p *Points) {
 	}
 }
 
+// MarshalXML encodes the points with the total as it appears in a response.
+// TotalStr is used unless Total has been changed to a different number.
+func (p Points) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type xmlPoints Points
+	total, err := strconv.ParseFloat(p.TotalStr, 64)
+	if p.Total != 0 && (err != nil || total != p.Total) &&
+		!(math.IsNaN(total) && math.IsNaN(p.Total)) {
+
+		p.TotalStr = strconv.FormatFloat(p.Total, 'f', -1, 64)
+	}
+	return e.EncodeElement(xmlPoints(p), start)
+}
+
 func (p *xmlContentProvider) RequestCount() int {
 	return p.client.RequestCount()
 }
@@ -573,22 +1599,96 @@ func (p *xmlContentProvider) RequestCount() int {
 
 // Get returns the HTTP response of a GET request to the given URL.
 func (o *countingHTTPApiClient) Get(url string) (*http.Response, error) {
-	o.requestCount++
-	response, err := o.client.Get(url)
+	return o.getTraced(url, trace{})
+}
 
-	// Known issue where "consumer_key_unknown" is returned for valid
-	// consumer keys. If this happens, try re-requesting the content a few
-	// times to see if it fixes itself
-	//
-	// See https://developer.yahoo.com/forum/OAuth-General-Discussion-YDN-SDKs/oauth-problem-consumer-key-unknown-/1375188859720-5cea9bdb-0642-4606-9fd5-c5f369112959
-	for attempts := 0; attempts < 4 &&
-		err != nil &&
-		strings.Contains(err.Error(), "consumer_key_unknown"); attempts++ {
+// Do returns the HTTP response of the given request. If the underlying
+// HTTPClient can't send arbitrary requests, a GET request is made to the
+// request's URL instead.
+func (o *countingHTTPApiClient) Do(request *http.Request) (*http.Response, error) {
+	return o.doTraced(request, trace{})
+}
 
-		o.requestCount++
-		response, err = o.client.Get(url)
+func (o *countingHTTPApiClient) getTraced(
+	url string,
+	t trace) (*http.Response, error) {
+
+	return o.send(context.Background(), url, t, func() (*http.Response, error) {
+		return o.client.Get(url)
+	})
+}
+
+func (o *countingHTTPApiClient) doTraced(
+	request *http.Request,
+	t trace) (*http.Response, error) {
+
+	attempts := 0
+	url := request.URL.String()
+	return o.send(request.Context(), url, t, func() (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return do(o.client, request)
+		}
+		retry, err := rewind(request)
+		if err != nil {
+			return nil, err
+		}
+		return do(o.client, retry)
+	})
+}
+
+// rewind returns a copy of the request that can be sent again, with its body
+// recreated using the request's GetBody function.
+func rewind(request *http.Request) (*http.Request, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	if request.GetBody == nil {
+		return nil, errors.New("request body can't be sent again")
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := request.Clone(request.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// send counts and makes a request to the API for the URL, retrying failures
+// according to the client's RetryPolicy until the context is done and
+// recording the amount of retries in the trace.
+func (o *countingHTTPApiClient) send(
+	ctx context.Context,
+	url string,
+	t trace,
+	request func() (*http.Response, error)) (*http.Response, error) {
+
+	policy := o.retryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	sleep := o.sleep
+	if sleep == nil {
+		sleep = sleepContext
 	}
 
+	attempts := 0
+	response, err := policy.retry(
+		ctx,
+		url,
+		func() (*http.Response, error) {
+			attempts++
+			response, err := request()
+			// Requests rejected by the client never reach the API
+			if !errors.Is(err, ErrRequestUnsupported) {
+				atomic.AddInt64(&o.requestCount, 1)
+			}
+			return response, err
+		},
+		sleep)
+	t.setRoot(attributeRetries, attempts-1)
+
 	if err != nil &&
 		strings.Contains(
 			err.Error(),
@@ -600,7 +1700,32 @@ func (o *countingHTTPApiClient) Get(url string) (*http.Response, error) {
 }
 
 func (o *countingHTTPApiClient) RequestCount() int {
-	return o.requestCount
+	return int(atomic.LoadInt64(&o.requestCount))
+}
+
+// do sends the request using the client if it implements HTTPRequestClient.
+// Otherwise, a GET request is made to the request's URL if that sends the
+// entire request, and ErrRequestUnsupported is returned if it doesn't.
+func do(client HTTPClient, request *http.Request) (*http.Response, error) {
+	if !canSend(client, request) {
+		return nil, ErrRequestUnsupported
+	}
+	if requestClient, ok := client.(HTTPRequestClient); ok {
+		return requestClient.Do(request)
+	}
+	return client.Get(request.URL.String())
+}
+
+// canSend reports whether the client can send the entire request, which is
+// always the case for a HTTPRequestClient. Other clients can only send GET
+// requests without a body or headers.
+func canSend(client HTTPClient, request *http.Request) bool {
+	if _, ok := client.(HTTPRequestClient); ok {
+		return true
+	}
+	return (request.Method == "" || request.Method == http.MethodGet) &&
+		(request.Body == nil || request.Body == http.NoBody) &&
+		len(request.Header) == 0
 }
 
 //
@@ -611,7 +1736,32 @@ func (o *countingHTTPApiClient) RequestCount() int {
 //
 // See http://developer.yahoo.com/fantasysports/guide/ for more information
 func (c *Client) GetFantasyContent(url string) (*FantasyContent, error) {
-	return c.Provider.Get(url)
+	return c.get("GetFantasyContent", url)
+}
+
+// get returns the content for the URL from the client's provider, tracing
+// and logging the request for the client function with the given name.
+func (c *Client) get(name string, url string) (*FantasyContent, error) {
+	if c.Tracer == nil && c.Logger == nil {
+		return c.Provider.Get(url)
+	}
+
+	t := newTrace(c.Tracer, c.Logger, "goff."+name, url)
+	content, err := getTraced(c.Provider, url, t)
+	t.finish(err)
+	return content, err
+}
+
+// getFinal requests content for the URL that will no longer change, so that
+// cached clients using an LRUCache keep it for as long as it stays in the
+// cache instead of for a single cache period. Other caches keep it for a
+// single cache period, like any other content.
+func (c *Client) getFinal(name string, url string) (*FantasyContent, error) {
+	t := newTrace(c.Tracer, c.Logger, "goff."+name, url)
+	t.final = true
+	content, err := getTraced(c.Provider, url, t)
+	t.finish(err)
+	return content, err
 }
 
 //
@@ -625,12 +1775,11 @@ func (c *Client) GetUserLeagues(year string) ([]League, error) {
 	if !ok {
 		return nil, fmt.Errorf("data not available for year=%s", year)
 	}
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/users;use_login=1/games;game_keys=%s/leagues",
-			YahooBaseURL,
-			yearKey))
+	content, err := c.get(
+		"GetUserLeagues",
+		c.URL().Users().Games(yearKey).Leagues().String())
 
-	if err != nil {
+	if failed(err) {
 		return nil, err
 	}
 
@@ -639,139 +1788,263 @@ func (c *Client) GetUserLeagues(year string) ([]League, error) {
 	}
 
 	if len(content.Users[0].Games) == 0 {
-		return make([]League, 0), nil
+		return make([]League, 0), err
 	}
 
-	return content.Users[0].Games[0].Leagues, nil
+	return content.Users[0].Games[0].Leagues, err
 }
 
 // GetPlayersStats returns a list of Players containing their stats for the
 // given week in the given year.
 func (c *Client) GetPlayersStats(leagueKey string, week int, players []Player) ([]Player, error) {
-	playerKeys := ""
+	playerKeys := make([]string, len(players))
 	for index, player := range players {
-		if index != 0 {
-			playerKeys += ","
-		}
-		playerKeys += player.PlayerKey
+		playerKeys[index] = player.PlayerKey
 	}
 
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/players;player_keys=%s/stats;type=week;week=%d",
-			YahooBaseURL,
-			leagueKey,
-			playerKeys,
-			week))
+	content, err := c.get(
+		"GetPlayersStats",
+		c.URL().League(leagueKey).
+			Players(playerKeys...).
+			Stats().Type("week").Week(week).
+			String())
 
-	if err != nil {
+	if failed(err) {
 		return nil, err
 	}
-	return content.League.Players, nil
+	return content.League.Players, err
+}
+
+// GetPlayerDetails returns the players with the given keys in the given
+// league, including their percent owned, percent started, and ownership in the
+// league. Players are requested in groups of up to 25.
+func (c *Client) GetPlayerDetails(leagueKey string, playerKeys []string) ([]Player, error) {
+	return getPlayersInGroups(
+		playerKeys,
+		func(playerKeys []string) (*FantasyContent, error) {
+			return c.get(
+				"GetPlayerDetails",
+				c.URL().League(leagueKey).
+					Players(playerKeys...).
+					Out("percent_owned", "percent_started", "ownership").
+					String())
+		})
+}
+
+// GetPlayersProjections returns a list of Players containing their projected
+// points and stats for the given week, in PlayerPoints and PlayerStats. Like
+// GetPlayerDetails, players are requested in groups of up to 25.
+func (c *Client) GetPlayersProjections(leagueKey string, week int, playerKeys []string) ([]Player, error) {
+	return getPlayersInGroups(
+		playerKeys,
+		func(playerKeys []string) (*FantasyContent, error) {
+			return c.get(
+				"GetPlayersProjections",
+				c.URL().League(leagueKey).
+					Players(playerKeys...).
+					Stats().Type("projected_week").Week(week).
+					String())
+		})
+}
+
+// maxPlayersPerRequest is the most players Yahoo returns in a single response
+const maxPlayersPerRequest = 25
+
+// getPlayersInGroups gets the players with the given keys using the get
+// function, which is called for each group of up to maxPlayersPerRequest
+// keys, and returns the players of every group in order.
+func getPlayersInGroups(
+	playerKeys []string,
+	get func(playerKeys []string) (*FantasyContent, error)) ([]Player, error) {
+
+	var staleErr error
+	players := make([]Player, 0, len(playerKeys))
+	for start := 0; start < len(playerKeys); start += maxPlayersPerRequest {
+		end := start + maxPlayersPerRequest
+		if end > len(playerKeys) {
+			end = len(playerKeys)
+		}
+		content, err := get(playerKeys[start:end])
+		if failed(err) {
+			return nil, err
+		} else if err != nil {
+			staleErr = err
+		}
+		players = append(players, content.League.Players...)
+	}
+	return players, staleErr
+}
+
+// GetPlayersStatsHistory returns the points and stats of the players with the
+// given keys for every week of the given league's season up to its current
+// week, ordered by week and mapped by player key. The league must include
+// its start, current, and end weeks, e.g. as returned by GetLeagueMetadata,
+// and an error is returned if its end week, or the current week of a league
+// that isn't finished, is missing.
+//
+// One request is made for each week and group of up to 25 players, as Yahoo
+// returns at most 25 players at a time. Cached clients keep the weeks before
+// the current week, which no longer change, until they are evicted or
+// invalidated, so only the current week is requested again once the cache
+// period ends.
+func (c *Client) GetPlayersStatsHistory(league *League, playerKeys []string) (map[string][]PlayerWeekStats, error) {
+	if len(playerKeys) == 0 {
+		return nil, errors.New("no player keys given for stats history")
+	}
+
+	if league.EndWeek < 1 {
+		return nil, fmt.Errorf("league %s is missing its end week",
+			league.LeagueKey)
+	}
+	firstWeek := league.StartWeek
+	if firstWeek < 1 {
+		firstWeek = 1
+	}
+	lastWeek := league.EndWeek
+	if !league.IsFinished {
+		// Without the current week, it is unknown which weeks are over
+		if league.CurrentWeek < 1 {
+			return nil, fmt.Errorf("league %s is missing its current week",
+				league.LeagueKey)
+		}
+		if league.CurrentWeek < lastWeek {
+			lastWeek = league.CurrentWeek
+		}
+	}
+
+	var staleErr error
+	history := make(map[string][]PlayerWeekStats)
+	for week := firstWeek; week <= lastWeek; week++ {
+		get := c.getFinal
+		if week == lastWeek && !league.IsFinished {
+			get = c.get
+		}
+		players, err := getPlayersInGroups(
+			playerKeys,
+			func(playerKeys []string) (*FantasyContent, error) {
+				return get(
+					"GetPlayersStatsHistory",
+					c.URL().League(league.LeagueKey).
+						Players(playerKeys...).
+						Stats().Type("week").Week(week).
+						String())
+			})
+		if failed(err) {
+			return nil, err
+		} else if err != nil {
+			staleErr = err
+		}
+		for _, player := range players {
+			history[player.PlayerKey] = append(
+				history[player.PlayerKey],
+				PlayerWeekStats{
+					Week:   week,
+					Points: player.PlayerPoints,
+					Stats:  player.PlayerStats.Stats,
+				})
+		}
+	}
+	return history, staleErr
 }
 
 // GetTeamRoster returns a team's roster for the given week.
 func (c *Client) GetTeamRoster(teamKey string, week int) ([]Player, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/team/%s/roster;week=%d",
-			YahooBaseURL,
-			teamKey,
-			week))
-	if err != nil {
+	content, err := c.get(
+		"GetTeamRoster",
+		c.URL().Team(teamKey).Roster().Week(week).String())
+	if failed(err) {
 		return nil, err
 	}
 
-	return content.Team.Roster.Players, nil
+	return content.Team.Roster.Players, err
 }
 
 // GetLeagueStandings gets a league containing the current standings.
 func (c *Client) GetLeagueStandings(leagueKey string) (*League, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s;out=standings,settings",
-			YahooBaseURL,
-			leagueKey))
-	if err != nil {
+	content, err := c.get(
+		"GetLeagueStandings",
+		c.URL().League(leagueKey).Out("standings", "settings").String())
+	if failed(err) {
 		return nil, err
 	}
-	return &content.League, nil
+	return &content.League, err
 }
 
 // GetAllTeamStats gets teams stats for a given week.
 func (c *Client) GetAllTeamStats(leagueKey string, week int) ([]Team, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/teams/stats;type=week;week=%d",
-			YahooBaseURL,
-			leagueKey,
-			week))
-	if err != nil {
+	content, err := c.get(
+		"GetAllTeamStats",
+		c.URL().League(leagueKey).
+			Teams().Stats().Type("week").Week(week).
+			String())
+	if failed(err) {
 		return nil, err
 	}
 
-	return content.League.Teams, nil
+	return content.League.Teams, err
 }
 
 // GetTeam returns all available information about the given team.
 func (c *Client) GetTeam(teamKey string) (*Team, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/team/%s;out=stats,metadata,players,standings,roster",
-			YahooBaseURL,
-			teamKey))
-	if err != nil {
+	content, err := c.get(
+		"GetTeam",
+		c.URL().Team(teamKey).
+			Out("stats", "metadata", "players", "standings", "roster").
+			String())
+	if failed(err) {
 		return nil, err
 	}
 
 	if content.Team.TeamID == 0 {
 		return nil, fmt.Errorf("no team returned for key='%s'", teamKey)
 	}
-	return &content.Team, nil
+	return &content.Team, err
 }
 
 // GetLeagueMetadata returns the metadata associated with the given league.
 func (c *Client) GetLeagueMetadata(leagueKey string) (*League, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/metadata",
-			YahooBaseURL,
-			leagueKey))
-	if err != nil {
+	content, err := c.get(
+		"GetLeagueMetadata",
+		c.URL().League(leagueKey).Metadata().String())
+	if failed(err) {
 		return nil, err
 	}
-	return &content.League, nil
+	return &content.League, err
 }
 
 // GetLeagueSettings returns the settings associated with the given league.
 func (c *Client) GetLeagueSettings(leagueKey string) (*Settings, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/settings",
-			YahooBaseURL,
-			leagueKey))
-	if err != nil {
+	content, err := c.get(
+		"GetLeagueSettings",
+		c.URL().League(leagueKey).Settings().String())
+	if failed(err) {
 		return nil, err
 	}
-	return &content.League.Settings, nil
+	return &content.League.Settings, err
 }
 
 // GetAllTeams returns all teams playing in the given league.
 func (c *Client) GetAllTeams(leagueKey string) ([]Team, error) {
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/teams", YahooBaseURL, leagueKey))
-	if err != nil {
+	content, err := c.get(
+		"GetAllTeams",
+		c.URL().League(leagueKey).Teams().String())
+	if failed(err) {
 		return nil, err
 	}
-	return content.League.Teams, nil
+	return content.League.Teams, err
 }
 
 // GetMatchupsForWeekRange returns a list of matchups for each week in the
 // requested range.
 func (c *Client) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]Matchup, error) {
-	leagueList := strconv.Itoa(startWeek)
+	weeks := []int{startWeek}
 	for i := startWeek + 1; i <= endWeek; i++ {
-		leagueList += "," + strconv.Itoa(i)
+		weeks = append(weeks, i)
 	}
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/league/%s/scoreboard;week=%s",
-			YahooBaseURL,
-			leagueKey,
-			leagueList))
-	if err != nil {
+	content, err := c.get(
+		"GetMatchupsForWeekRange",
+		c.URL().League(leagueKey).Scoreboard().Weeks(weeks...).String())
+	if failed(err) {
 		return nil, err
 	}
 
@@ -784,24 +2057,18 @@ func (c *Client) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek in
 		}
 		all[week] = append(list, matchup)
 	}
-	return all, nil
+	return all, err
 }
 
 // GetTeamMatchupsForWeekRange returns a list of a team's matchups for the
 // provided weeks.
 func (c *Client) GetTeamMatchupsForWeeks(teamKey string, weeks []int) ([]Matchup, error) {
-	weeksList := strconv.Itoa(weeks[0])
-	for _, w := range weeks {
-		weeksList += "," + strconv.Itoa(w)
-	}
-	content, err := c.GetFantasyContent(
-		fmt.Sprintf("%s/team/%s/matchups;weeks=%s",
-			YahooBaseURL,
-			teamKey,
-			weeksList))
-	if err != nil {
+	content, err := c.get(
+		"GetTeamMatchupsForWeeks",
+		c.URL().Team(teamKey).Matchups().MatchupWeeks(weeks...).String())
+	if failed(err) {
 		return nil, err
 	}
 
-	return content.Team.Matchups, nil
+	return content.Team.Matchups, err
 }
@@ -1,11 +1,18 @@
 package goff
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -50,6 +57,53 @@ func TestNewCachedClient(t *testing.T) {
 	}
 }
 
+//
+// Test NewCachedUserClient
+//
+
+func TestNewCachedUserClientDoesNotShareContent(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	user1Client := NewCachedUserClient(
+		"user1",
+		cache,
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)})
+	user2Client := NewCachedUserClient(
+		"user2",
+		cache,
+		&mockHTTPClient{Response: mockResponse(teamXMLContent)})
+
+	url := YahooBaseURL + "/users;use_login=1/games;game_keys=nfl/leagues"
+	user1Content, err := user1Client.GetFantasyContent(url)
+	if err != nil {
+		t.Fatalf("Unexpected error for first user: %s", err)
+	}
+
+	user2Content, err := user2Client.GetFantasyContent(url)
+	if err != nil {
+		t.Fatalf("Unexpected error for second user: %s", err)
+	}
+
+	if user1Content == user2Content {
+		t.Fatal("Content for one user returned to another")
+	}
+	assertLeaguesEqual(t,
+		[]League{expectedLeague},
+		[]League{user1Content.League})
+	assertTeamsEqual(t, &expectedTeam, &user2Content.Team)
+
+	assertIntEquals(t, 1, user1Client.RequestCount())
+	assertIntEquals(t, 1, user2Client.RequestCount())
+
+	cachedContent, err := user1Client.GetFantasyContent(url)
+	if err != nil {
+		t.Fatalf("Unexpected error for first user: %s", err)
+	}
+	if cachedContent != user1Content {
+		t.Fatal("Cached content not returned to the same user")
+	}
+	assertIntEquals(t, 1, user1Client.RequestCount())
+}
+
 //
 // Test GetConsumer
 //
@@ -63,6 +117,35 @@ func TestGetConsumer(t *testing.T) {
 	}
 }
 
+func TestGetEndpointConsumer(t *testing.T) {
+	consumer := GetEndpointConsumer("clientID", "clientSecret", OAuthEndpoints{
+		RequestTokenURL:   "https://example.com/get_request_token",
+		AuthorizeTokenURL: "https://example.com/request_auth",
+		AccessTokenURL:    "https://example.com/get_token",
+	})
+	httpClient := &mockHTTPClient{}
+	consumer.HttpClient = httpClient
+	respond := func(body string) {
+		httpClient.Response = mockResponse(body)
+		httpClient.Response.StatusCode = http.StatusOK
+	}
+
+	respond("oauth_token=request&oauth_token_secret=secret" +
+		"&oauth_callback_confirmed=true")
+	requestToken, authorizeURL, err := consumer.GetRequestTokenAndUrl("oob")
+	if err != nil {
+		t.Fatalf("error getting request token: %s", err)
+	}
+	assertURLHasPrefix(t, "https://example.com/get_request_token", httpClient.LastURL)
+	assertURLHasPrefix(t, "https://example.com/request_auth?", authorizeURL)
+
+	respond("oauth_token=access&oauth_token_secret=secret")
+	if _, err := consumer.AuthorizeToken(requestToken, "verifier"); err != nil {
+		t.Fatalf("error getting access token: %s", err)
+	}
+	assertURLHasPrefix(t, "https://example.com/get_token", httpClient.LastURL)
+}
+
 //
 // Test lruCache
 //
@@ -120,6 +203,77 @@ func TestGetKey(t *testing.T) {
 	}
 }
 
+func TestGetKeyForUser(t *testing.T) {
+	clientID := "clientID"
+	userID := "userID"
+	duration := time.Hour
+	lruCache := &lru.LRUCache{}
+	cache := NewLRUCache(clientID, duration, lruCache).ForUser(userID)
+
+	originalKey := "key"
+	time := time.Unix(1408281677, 0)
+	expectedKey := fmt.Sprintf("%s@%s:%s:%s",
+		clientID,
+		userID,
+		originalKey,
+		"391189")
+
+	key := cache.(*LRUCache).getKey(originalKey, time)
+
+	if key != expectedKey {
+		t.Fatalf("Did not received expected key\n\texpected: %s"+
+			"\n\tactual: %s",
+			expectedKey,
+			key)
+	}
+}
+
+func TestForUserDoesNotShareContent(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache(clientID, duration, lruCache)
+	user1 := cache.ForUser("user1")
+	user2 := cache.ForUser("user2")
+
+	time := time.Unix(1408281677, 0)
+	url := YahooBaseURL + "/users;use_login=1/games;game_keys=nfl/leagues"
+	user1Content := createLeagueList(League{LeagueKey: "123"})
+	user1.Set(url, time, user1Content)
+
+	if content, ok := user2.Get(url, time); ok {
+		t.Fatalf("Content cached for one user returned for another\n\t"+
+			"content: %+v",
+			content)
+	}
+
+	if content, ok := cache.Get(url, time); ok {
+		t.Fatalf("Content cached for a user returned without a user\n\t"+
+			"content: %+v",
+			content)
+	}
+
+	content, ok := user1.Get(url, time)
+	if !ok || content != user1Content {
+		t.Fatalf("Cache did not return content for user\n\t"+
+			"expected: %+v\n\tactual: %+v",
+			user1Content,
+			content)
+	}
+
+	if entries := cache.Entries(""); len(entries) != 0 {
+		t.Fatalf("Entries for a user returned without a user: %+v", entries)
+	}
+
+	if entries := user2.(*LRUCache).Entries(""); len(entries) != 0 {
+		t.Fatalf("Entries for one user returned for another: %+v", entries)
+	}
+
+	if entries := user1.(*LRUCache).Entries(""); len(entries) != 1 {
+		t.Fatalf("Unexpected entries for user: %+v", entries)
+	}
+}
+
 func TestGetNoContent(t *testing.T) {
 	clientID := "clientID"
 	duration := time.Hour
@@ -204,6 +358,13 @@ func TestSet(t *testing.T) {
 		t.Fatalf("Incorrect type used in LRU cache: %T", value)
 	}
 
+	if lruCacheValue.Size() != 1 {
+		t.Fatalf("Unexpected size of value in cache\n\texpected: %d\n\t"+
+			"actual: %d",
+			1,
+			lruCacheValue.Size())
+	}
+
 	if lruCacheValue.content != expectedContent {
 		t.Fatalf("Unepxected content in cache\n\texpected: %+v\n\t"+
 			"actual: %+v",
@@ -222,6 +383,354 @@ func TestLRUCacheValueSize(t *testing.T) {
 	}
 }
 
+func TestGetStale(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache(clientID, duration, lruCache)
+
+	setTime := time.Unix(1408281677, 0)
+	url := "http://example.com/fantasy"
+	expectedContent := createLeagueList(League{LeagueKey: "123"})
+	cache.Set(url, setTime, expectedContent)
+
+	content, ok := cache.GetStale(url, setTime)
+	if ok {
+		t.Fatalf("Cache returned stale content for the current period\n\t"+
+			"content: %+v",
+			content)
+	}
+
+	content, ok = cache.PeekStale(url, setTime.Add(duration))
+	if !ok || content != expectedContent {
+		t.Fatalf("Cache did not peek at expected stale content: %+v", content)
+	}
+	assertInt64Equals(t, 0, cache.Stats().Stale)
+
+	content, ok = cache.GetStale(url, setTime.Add(duration))
+	if !ok {
+		t.Fatal("Cache did not return stale content")
+	}
+
+	if content != expectedContent {
+		t.Fatalf("Cache did not return expected stale content\n\t"+
+			"expected: %+v\n\tactual: %+v",
+			expectedContent,
+			content)
+	}
+
+	stats := cache.Stats()
+	if stats.Stale != 1 {
+		t.Fatalf("Unexpected stale count\n\texpected: %d\n\tactual: %d",
+			1,
+			stats.Stale)
+	}
+}
+
+func TestLRUCacheStats(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache(clientID, duration, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	leagueURL := YahooBaseURL + "/league/223.l.431;out=standings"
+	teamURL := YahooBaseURL + "/team/223.l.431.t.1/roster;week=2"
+	content := createLeagueList(League{LeagueKey: "123"})
+	cache.Set(leagueURL, time, content)
+
+	cache.Get(leagueURL, time)
+	cache.Get(leagueURL, time)
+	cache.Get(teamURL, time)
+
+	stats := cache.Stats()
+	assertInt64Equals(t, 2, stats.Hits)
+	assertInt64Equals(t, 1, stats.Misses)
+	assertInt64Equals(t, 0, stats.Stale)
+	assertInt64Equals(t, 1, stats.Entries)
+	assertInt64Equals(t, 1, stats.Size)
+	assertInt64Equals(t, 0, stats.Evictions)
+
+	league := stats.Resources["league"]
+	assertInt64Equals(t, 2, league.Hits)
+	assertInt64Equals(t, 0, league.Misses)
+
+	team := stats.Resources["team"]
+	assertInt64Equals(t, 0, team.Hits)
+	assertInt64Equals(t, 1, team.Misses)
+}
+
+func TestLRUCacheStatsSharedBackingCache(t *testing.T) {
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache("clientID", time.Hour, lruCache)
+	other := NewLRUCache("otherClientID", time.Hour, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	content := createLeagueList(League{LeagueKey: "123"})
+	cache.Set("http://example.com/1", time, content)
+	cache.ForUser("user1").Set("http://example.com/2", time, content)
+	other.Set("http://example.com/1", time, content)
+	other.Set("http://example.com/2", time, content)
+	other.Set("http://example.com/3", time, content)
+
+	stats := cache.Stats()
+	assertInt64Equals(t, 2, stats.Entries)
+	assertInt64Equals(t, 2, stats.Size)
+
+	stats = other.Stats()
+	assertInt64Equals(t, 3, stats.Entries)
+	assertInt64Equals(t, 3, stats.Size)
+}
+
+func TestLRUCacheStatsEvictions(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(2)
+	cache := NewLRUCache(clientID, duration, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	content := createLeagueList(League{LeagueKey: "123"})
+	cache.Set("http://example.com/1", time, content)
+	cache.Set("http://example.com/2", time, content)
+	cache.Set("http://example.com/2", time, content)
+	cache.Set("http://example.com/3", time, content)
+	cache.Set("http://example.com/4", time, content)
+
+	stats := cache.Stats()
+	assertInt64Equals(t, 2, stats.Evictions)
+	assertInt64Equals(t, 2, stats.Entries)
+}
+
+func TestLRUCacheStatsEvictionsConcurrent(t *testing.T) {
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache("clientID", time.Hour, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	content := createLeagueList(League{LeagueKey: "123"})
+	var wait sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			user := cache.ForUser(fmt.Sprintf("user%d", i%2))
+			user.Set(fmt.Sprintf("http://example.com/%d", i), time, content)
+		}(i)
+	}
+	wait.Wait()
+
+	stats := cache.Stats()
+	assertInt64Equals(t, 90, stats.Evictions)
+	assertInt64Equals(t, 10, stats.Entries)
+}
+
+func TestLRUCacheEntries(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache(clientID, duration, lruCache)
+
+	setTime := time.Unix(1408281677, 0)
+	leaguePrefix := YahooBaseURL + "/league/223.l.431"
+	expectedContent := createLeagueList(League{LeagueKey: "223.l.431"})
+	cache.Set(leaguePrefix+"/teams", setTime, expectedContent)
+	cache.Set(leaguePrefix+"/settings", setTime, expectedContent)
+	cache.Set(YahooBaseURL+"/league/223.l.999/teams", setTime, expectedContent)
+	lruCache.Set("otherClientID:"+leaguePrefix+":391189", &LRUCacheValue{})
+
+	entries := cache.Entries(leaguePrefix)
+	if len(entries) != 2 {
+		t.Fatalf("Unexpected amount of entries\n\texpected: %d\n\t"+
+			"actual: %d\n\tentries: %+v",
+			2,
+			len(entries),
+			entries)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.URL, leaguePrefix) {
+			t.Fatalf("Entry returned for unexpected URL: %s", entry.URL)
+		}
+		if entry.Content != expectedContent {
+			t.Fatalf("Unexpected content in entry\n\texpected: %+v\n\t"+
+				"actual: %+v",
+				expectedContent,
+				entry.Content)
+		}
+		if entry.Key != cache.getKey(entry.URL, setTime) {
+			t.Fatalf("Unexpected key in entry: %s", entry.Key)
+		}
+		expectedTime := setTime.Truncate(duration)
+		if !entry.Time.Equal(expectedTime) {
+			t.Fatalf("Unexpected time in entry\n\texpected: %s\n\t"+
+				"actual: %s",
+				expectedTime,
+				entry.Time)
+		}
+	}
+}
+
+func TestLRUCacheFinal(t *testing.T) {
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache("clientID", time.Hour, lruCache)
+
+	url := YahooBaseURL + "/league/223.l.431/players;player_keys=223.p.1/stats"
+	content := createLeagueList(League{LeagueKey: "223.l.431"})
+	cache.setFinal(url, content)
+
+	if actual, ok := cache.getFinal(url, false); !ok || actual != content {
+		t.Fatalf("Final content not returned by cache: %+v", actual)
+	}
+	if _, ok := cache.Get(url, time.Unix(0, 0)); ok {
+		t.Fatal("Final content returned for a cache period")
+	}
+
+	entries := cache.Entries(url)
+	assertIntEquals(t, 1, len(entries))
+	if !entries[0].Final || !entries[0].Time.IsZero() {
+		t.Fatalf("Unexpected final entry: %+v", entries[0])
+	}
+	assertStringEquals(t, cache.getFinalKey(url), entries[0].Key)
+	assertStringEquals(t, url, entries[0].URL)
+
+	assertIntEquals(t, 1, cache.Invalidate(url))
+	if _, ok := cache.getFinal(url, false); ok {
+		t.Fatal("Invalidated final content still returned by cache")
+	}
+}
+
+func TestLRUCacheInvalidate(t *testing.T) {
+	clientID := "clientID"
+	duration := time.Hour
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache(clientID, duration, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	leaguePrefix := YahooBaseURL + "/league/223.l.431"
+	otherURL := YahooBaseURL + "/league/223.l.999/teams"
+	content := createLeagueList(League{LeagueKey: "223.l.431"})
+	cache.Set(leaguePrefix+"/teams", time, content)
+	cache.Set(leaguePrefix+"/settings", time, content)
+	cache.Set(otherURL, time, content)
+
+	removed := cache.Invalidate(leaguePrefix)
+	assertIntEquals(t, 2, removed)
+
+	if _, ok := cache.Get(leaguePrefix+"/teams", time); ok {
+		t.Fatal("Invalidated content still returned by cache")
+	}
+
+	if _, ok := cache.Get(otherURL, time); !ok {
+		t.Fatal("Content not matching prefix was invalidated")
+	}
+}
+
+func TestLRUCacheInvalidateForUsers(t *testing.T) {
+	lruCache := lru.NewLRUCache(10)
+	cache := NewLRUCache("clientID", time.Hour, lruCache)
+	user1 := cache.ForUser("user1").(*LRUCache)
+	user2 := cache.ForUser("user2").(*LRUCache)
+	other := NewLRUCache("otherClientID", time.Hour, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	url := YahooBaseURL + "/league/223.l.431/teams"
+	content := createLeagueList(League{LeagueKey: "223.l.431"})
+	cache.Set(url, time, content)
+	user1.Set(url, time, content)
+	user2.Set(url, time, content)
+	other.Set(url, time, content)
+
+	assertIntEquals(t, 3, user1.Invalidate(url))
+
+	if _, ok := user2.Get(url, time); ok {
+		t.Fatal("Content invalidated for one user still returned for another")
+	}
+
+	if _, ok := other.Get(url, time); !ok {
+		t.Fatal("Content of another client was invalidated")
+	}
+}
+
+func TestGetKeyEscapesIDs(t *testing.T) {
+	lruCache := lru.NewLRUCache(10)
+	first := NewLRUCache("client@a", time.Hour, lruCache).ForUser("b")
+	second := NewLRUCache("client", time.Hour, lruCache).ForUser("a@b")
+
+	time := time.Unix(1408281677, 0)
+	url := YahooBaseURL + "/users;use_login=1/games/leagues"
+	first.Set(url, time, createLeagueList(League{LeagueKey: "123"}))
+
+	if content, ok := second.Get(url, time); ok {
+		t.Fatalf("Content cached for one scope returned for another\n\t"+
+			"content: %+v",
+			content)
+	}
+
+	entries := first.(*LRUCache).Entries(url)
+	if len(entries) != 1 || entries[0].URL != url {
+		t.Fatalf("Unexpected entries for escaped IDs: %+v", entries)
+	}
+}
+
+func TestResourceType(t *testing.T) {
+	tests := map[string]string{
+		YahooBaseURL + "/league/223.l.431/teams":           "league",
+		YahooBaseURL + "/league/223.l.431;out=standings":   "league",
+		YahooBaseURL + "/team/223.l.431.t.1/roster;week=1": "team",
+		YahooBaseURL + "/users;use_login=1/games/leagues":  "users",
+		YahooBaseURL + "/players;player_keys=223.p.1":      "players",
+		YahooBaseURL + "/game?format=json":                 "game",
+		YahooBaseURL + "/":                                 "other",
+		"http://example.com/fantasy":                       "other",
+	}
+
+	for url, expected := range tests {
+		assertStringEquals(t, expected, ResourceType(url))
+	}
+}
+
+func TestLRUCacheValueSizeOfContent(t *testing.T) {
+	small := newLRUCacheValue(&FantasyContent{}, true)
+	large := newLRUCacheValue(&FantasyContent{
+		League: League{
+			Name:    strings.Repeat("a", 1000),
+			Players: make([]Player, 100),
+		},
+	}, true)
+
+	if small.Size() < int(reflect.TypeOf(FantasyContent{}).Size()) {
+		t.Fatalf("Size of empty content smaller than its type\n\t"+
+			"size: %d",
+			small.Size())
+	}
+
+	minimum := small.Size() + 1000 + 100*int(reflect.TypeOf(Player{}).Size())
+	if large.Size() < minimum {
+		t.Fatalf("Size of content does not include referenced data\n\t"+
+			"expected at least: %d\n\tactual: %d",
+			minimum,
+			large.Size())
+	}
+}
+
+func TestNewByteSizedLRUCache(t *testing.T) {
+	content := createLeagueList(League{LeagueKey: "123"})
+	lruCache := lru.NewLRUCache(int64(2 * contentSize(content)))
+	cache := NewByteSizedLRUCache("clientID", time.Hour, lruCache)
+
+	time := time.Unix(1408281677, 0)
+	cache.Set("http://example.com/1", time, content)
+
+	stats := cache.Stats()
+	assertInt64Equals(t, int64(contentSize(content)), stats.Size)
+
+	cache.Set("http://example.com/2", time, content)
+	cache.Set("http://example.com/3", time, content)
+
+	stats = cache.Stats()
+	assertInt64Equals(t, 2, stats.Entries)
+	assertInt64Equals(t, 1, stats.Evictions)
+}
+
 type mockedValue struct{}
 
 func (m mockedValue) Size() int {
@@ -323,6 +832,95 @@ func TestCountingHTTPClientAccessDeniedError(t *testing.T) {
 	}
 }
 
+func TestCountingHTTPClientConcurrentRequestCount(t *testing.T) {
+	client := &countingHTTPApiClient{
+		client: mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			return &http.Response{}, nil
+		}),
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			client.Get("http://example.com")
+			client.RequestCount()
+		}()
+	}
+	wait.Wait()
+
+	assertIntEquals(t, 50, client.RequestCount())
+}
+
+func TestCountingHTTPClientDo(t *testing.T) {
+	expected := &http.Response{}
+	httpClient := &mockHTTPClient{Response: expected}
+	client := &countingHTTPApiClient{client: httpClient}
+
+	request, _ := http.NewRequest("GET", "http://example.com", nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("received unexpected response from client")
+	}
+
+	if httpClient.LastRequest != request {
+		t.Fatalf("request not sent to client")
+	}
+	assertIntEquals(t, 1, client.RequestCount())
+}
+
+func TestCountingHTTPClientDoWithoutRequestClient(t *testing.T) {
+	expected := &http.Response{}
+	httpClient := &mockHTTPClient{Response: expected}
+	client := &countingHTTPApiClient{
+		client: &mockGetHTTPClient{HTTPClient: httpClient},
+	}
+
+	request, _ := http.NewRequest("GET", "http://example.com", nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("received unexpected response from client")
+	}
+
+	if httpClient.LastRequest != nil {
+		t.Fatalf("request sent to client without Do")
+	}
+	assertStringEquals(t, "http://example.com", httpClient.LastURL)
+}
+
+func TestCountingHTTPClientDoWithoutRequestClientUnsupported(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	client := &countingHTTPApiClient{
+		client: &mockGetHTTPClient{HTTPClient: httpClient},
+	}
+
+	request, _ := http.NewRequest("GET", "http://example.com", nil)
+	request.Header.Set("If-None-Match", `"abc"`)
+	if _, err := client.Do(request); err != ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for request with headers: %v", err)
+	}
+
+	request, _ = http.NewRequest(
+		"POST",
+		"http://example.com",
+		strings.NewReader("<fantasy_content/>"))
+	if _, err := client.Do(request); err != ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for POST request: %v", err)
+	}
+
+	assertStringEquals(t, "", httpClient.LastURL)
+	assertIntEquals(t, 0, client.RequestCount())
+}
+
 //
 // Test cachedContentProvider
 //
@@ -431,6 +1029,194 @@ func TestCachedGetNoContentInCacheErrorReturnedCacheNotSet(t *testing.T) {
 	}
 }
 
+func TestCachedGetErrorDoesNotReturnStaleContentByDefault(t *testing.T) {
+	cache := mockCache()
+	expectedErr := errors.New("error")
+	delegate := &mockedContentProvider{content: nil, err: expectedErr}
+	provider := &cachedContentProvider{
+		delegate: delegate,
+		cache:    cache,
+	}
+
+	url := "http://example.com/fantasy"
+	cache.stale[url] = createLeagueList(League{LeagueKey: "123"})
+	content, err := provider.Get(url)
+
+	if err != expectedErr {
+		t.Fatalf("Cached provider did not return expected error: \n\t"+
+			"expected: %s\n\tactual: %s",
+			expectedErr,
+			err)
+	}
+
+	if content != nil {
+		t.Fatalf("Cached provider returned stale content: %+v", content)
+	}
+	assertIntEquals(t, 0, cache.staleServed)
+}
+
+func TestCachedGetStaleOnErrorReturnsStaleContent(t *testing.T) {
+	cache := mockCache()
+	expectedContent := createLeagueList(League{LeagueKey: "123"})
+	expectedErr := errors.New("error")
+	delegate := &mockedContentProvider{content: nil, err: expectedErr}
+	provider := &cachedContentProvider{
+		delegate:     delegate,
+		cache:        cache,
+		staleOnError: true,
+	}
+
+	url := "http://example.com/fantasy"
+	cache.stale[url] = expectedContent
+	content, err := provider.Get(url)
+
+	if content != expectedContent {
+		t.Fatalf("Cached provider did not return stale content: %+v", content)
+	}
+
+	var staleErr *StaleContentError
+	if !errors.As(err, &staleErr) {
+		t.Fatalf("Cached provider did not return StaleContentError: %v", err)
+	}
+
+	if !errors.Is(err, expectedErr) {
+		t.Fatalf("StaleContentError does not wrap the delegate error: %s", err)
+	}
+
+	if staleErr.Content != expectedContent {
+		t.Fatalf("Actual content did not equal expected content\n"+
+			"\texpected: %+v\n\tactual: %+v",
+			expectedContent,
+			staleErr.Content)
+	}
+	assertIntEquals(t, 1, cache.staleServed)
+}
+
+func TestCachedGetAccessDeniedDoesNotReturnStaleContent(t *testing.T) {
+	cache := mockCache()
+	delegate := &mockedContentProvider{content: nil, err: ErrAccessDenied}
+	provider := &cachedContentProvider{
+		delegate:     delegate,
+		cache:        cache,
+		staleOnError: true,
+	}
+
+	url := "http://example.com/fantasy"
+	cache.stale[url] = createLeagueList(League{LeagueKey: "123"})
+	content, err := provider.Get(url)
+
+	if err != ErrAccessDenied {
+		t.Fatalf("Cached provider did not return expected error: \n\t"+
+			"expected: %s\n\tactual: %s",
+			ErrAccessDenied,
+			err)
+	}
+
+	if content != nil {
+		t.Fatalf("Cached provider returned stale content: %+v", content)
+	}
+}
+
+func TestCachedGetRevalidatesStaleContent(t *testing.T) {
+	cache := mockCache()
+	staleContent := createLeagueList(League{LeagueKey: "123"})
+	delegate := &mockedRevalidatingContentProvider{
+		mockedContentProvider: mockedContentProvider{content: staleContent},
+	}
+	provider := &cachedContentProvider{
+		delegate: delegate,
+		cache:    cache,
+	}
+
+	url := "http://example.com/fantasy"
+	cache.stale[url] = staleContent
+	actualContent, err := provider.Get(url)
+
+	if err != nil {
+		t.Fatalf("Cached provider returned error: %s", err)
+	}
+
+	if delegate.lastRevalidated != staleContent {
+		t.Fatalf("Stale content was not revalidated\n\texpected: %+v\n\t"+
+			"actual: %+v",
+			staleContent,
+			delegate.lastRevalidated)
+	}
+
+	if actualContent != staleContent {
+		t.Fatalf("Actual content did not equal expected content\n"+
+			"\texpected: %+v\n\tactual: %+v",
+			staleContent,
+			actualContent)
+	}
+
+	if cache.lastSetURL != url || cache.lastSetContent != staleContent {
+		t.Fatalf("Cache was not updated with revalidated content\n\t"+
+			"url: %s\n\tcontent: %+v",
+			cache.lastSetURL,
+			cache.lastSetContent)
+	}
+	assertIntEquals(t, 1, cache.staleServed)
+}
+
+func TestCachedGetModifiedStaleContentIsNotServed(t *testing.T) {
+	cache := mockCache()
+	expectedContent := createLeagueList(League{LeagueKey: "123"})
+	delegate := &mockedRevalidatingContentProvider{
+		mockedContentProvider: mockedContentProvider{content: expectedContent},
+	}
+	provider := &cachedContentProvider{
+		delegate: delegate,
+		cache:    cache,
+	}
+
+	url := "http://example.com/fantasy"
+	cache.stale[url] = createLeagueList(League{LeagueKey: "456"})
+	actualContent, err := provider.Get(url)
+
+	if err != nil {
+		t.Fatalf("Cached provider returned error: %s", err)
+	}
+
+	if actualContent != expectedContent {
+		t.Fatalf("Actual content did not equal expected content\n"+
+			"\texpected: %+v\n\tactual: %+v",
+			expectedContent,
+			actualContent)
+	}
+	assertIntEquals(t, 0, cache.staleServed)
+}
+
+func TestCachedGetWithoutStaleContentDoesNotRevalidate(t *testing.T) {
+	cache := mockCache()
+	expectedContent := createLeagueList(League{LeagueKey: "123"})
+	delegate := &mockedRevalidatingContentProvider{
+		mockedContentProvider: mockedContentProvider{content: expectedContent},
+	}
+	provider := &cachedContentProvider{
+		delegate: delegate,
+		cache:    cache,
+	}
+
+	actualContent, err := provider.Get("http://example.com/fantasy")
+
+	if err != nil {
+		t.Fatalf("Cached provider returned error: %s", err)
+	}
+
+	if delegate.lastRevalidated != nil {
+		t.Fatalf("Content revalidated without stale content: %+v",
+			delegate.lastRevalidated)
+	}
+
+	if actualContent != expectedContent {
+		t.Fatalf("Actual content did not equal expected content\n"+
+			"\texpected: %+v\n\tactual: %+v",
+			expectedContent,
+			actualContent)
+	}
+}
+
 //
 // Test xmlContentProvider
 //
@@ -525,6 +1311,149 @@ func TestXMLContentProviderParseError(t *testing.T) {
 	}
 }
 
+func TestXMLContentProviderStoresValidators(t *testing.T) {
+	response := mockResponse(leagueXMLContent)
+	response.Header = http.Header{}
+	response.Header.Set("ETag", `"etag"`)
+	response.Header.Set("Last-Modified", "Mon, 17 Aug 2015 13:21:17 GMT")
+	client := &countingHTTPApiClient{
+		client: &mockHTTPClient{Response: response},
+	}
+
+	provider := &xmlContentProvider{client: client}
+	content, err := provider.Get("http://example.com")
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	assertStringEquals(t, `"etag"`, content.etag)
+	assertStringEquals(t, "Mon, 17 Aug 2015 13:21:17 GMT", content.lastModified)
+}
+
+func TestXMLContentProviderRevalidateNotModified(t *testing.T) {
+	response := mockResponse("")
+	response.StatusCode = http.StatusNotModified
+	httpClient := &mockHTTPClient{Response: response}
+	client := &countingHTTPApiClient{client: httpClient}
+
+	provider := &xmlContentProvider{client: client}
+	cached := createLeagueList(League{LeagueKey: "123"})
+	cached.etag = `"etag"`
+	cached.lastModified = "Mon, 17 Aug 2015 13:21:17 GMT"
+	content, err := provider.revalidate("http://example.com", cached, trace{})
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if content != cached {
+		t.Fatalf("Cached content not returned when not modified\n\t"+
+			"expected: %+v\n\tactual: %+v",
+			cached,
+			content)
+	}
+
+	request := httpClient.LastRequest
+	if request == nil {
+		t.Fatal("No conditional request made")
+	}
+	assertStringEquals(t, "http://example.com", request.URL.String())
+	assertStringEquals(t, `"etag"`, request.Header.Get("If-None-Match"))
+	assertStringEquals(t,
+		"Mon, 17 Aug 2015 13:21:17 GMT",
+		request.Header.Get("If-Modified-Since"))
+	assertIntEquals(t, 1, provider.RequestCount())
+}
+
+func TestXMLContentProviderRevalidateModified(t *testing.T) {
+	response := mockResponse(leagueXMLContent)
+	response.StatusCode = http.StatusOK
+	response.Header = http.Header{}
+	response.Header.Set("ETag", `"new-etag"`)
+	client := &countingHTTPApiClient{
+		client: &mockHTTPClient{Response: response},
+	}
+
+	provider := &xmlContentProvider{client: client}
+	cached := createLeagueList(League{LeagueKey: "123"})
+	cached.etag = `"etag"`
+	content, err := provider.revalidate("http://example.com", cached, trace{})
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if content == cached {
+		t.Fatal("Cached content returned after it was modified")
+	}
+	assertLeaguesEqual(t, []League{expectedLeague}, []League{content.League})
+	assertStringEquals(t, `"new-etag"`, content.etag)
+}
+
+func TestXMLContentProviderRevalidateWithoutValidators(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueXMLContent)}
+	client := &countingHTTPApiClient{client: httpClient}
+
+	provider := &xmlContentProvider{client: client}
+	cached := createLeagueList(League{LeagueKey: "123"})
+	content, err := provider.revalidate("http://example.com", cached, trace{})
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if httpClient.LastRequest != nil {
+		t.Fatalf("Conditional request made without validators: %+v",
+			httpClient.LastRequest)
+	}
+	assertLeaguesEqual(t, []League{expectedLeague}, []League{content.League})
+}
+
+func TestXMLContentProviderRevalidateUnsupported(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueXMLContent)}
+	limiter := NewRateLimiter(RateLimit{PerHour: 10})
+	client := &countingHTTPApiClient{
+		client: limiter.Client(&mockGetHTTPClient{httpClient}),
+	}
+
+	provider := &xmlContentProvider{client: client}
+	cached := createLeagueList(League{LeagueKey: "123"})
+	cached.etag = `"abc"`
+	content, err := provider.revalidate("http://example.com", cached, trace{})
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if httpClient.LastRequest != nil {
+		t.Fatalf("Conditional request made by client without support: %+v",
+			httpClient.LastRequest)
+	}
+	assertLeaguesEqual(t, []League{expectedLeague}, []League{content.League})
+	assertIntEquals(t, 9, limiter.Remaining().PerHour)
+	assertIntEquals(t, 1, client.RequestCount())
+}
+
+func TestXMLContentProviderRevalidateError(t *testing.T) {
+	client := &countingHTTPApiClient{
+		client: &mockHTTPClient{
+			Response:   mockResponse(""),
+			Error:      errors.New("error"),
+			ErrorCount: 1,
+		},
+	}
+
+	provider := &xmlContentProvider{client: client}
+	cached := createLeagueList(League{LeagueKey: "123"})
+	cached.etag = `"etag"`
+	_, err := provider.revalidate("http://example.com", cached, trace{})
+
+	if err == nil {
+		t.Fatalf("error not returned when conditional request fails")
+	}
+}
+
 func TestXMLContentProviderEmptyTagsForNumberFields(t *testing.T) {
 	response := mockResponse(`
 <?xml version="1.0" encoding="UTF-8"?>
@@ -693,31 +1622,395 @@ func checkTeam(t *testing.T, team *Team, expectedPoints float64, expectedRank in
 			expectedPoints)
 	}
 
-	if team.Roster.Players[0].PlayerPoints.Total != expectedPoints {
-		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %f\n\t"+
-			"expected: %f",
-			"Roster.Players.PlayerPoints.Total",
-			team.Roster.Players[0].PlayerPoints.Total,
-			expectedPoints)
+	if team.Roster.Players[0].PlayerPoints.Total != expectedPoints {
+		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %f\n\t"+
+			"expected: %f",
+			"Roster.Players.PlayerPoints.Total",
+			team.Roster.Players[0].PlayerPoints.Total,
+			expectedPoints)
+	}
+
+	if team.Players[0].PlayerPoints.Total != expectedPoints {
+		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %f\n\t"+
+			"expected: %f",
+			"Players.PlayerPoints.Total",
+			team.Players[0].PlayerPoints.Total,
+			expectedPoints)
+	}
+
+	if team.TeamStandings.Rank != expectedRank {
+		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %d\n\t"+
+			"expected: %d",
+			"TeamStandings.Rank",
+			team.TeamStandings.Rank,
+			expectedRank)
+	}
+}
+
+func TestFixContentMalformedMatchups(t *testing.T) {
+	bye := Team{
+		TeamKey:    "223.l.431.t.1",
+		TeamPoints: Points{TotalStr: "12.5"},
+		Matchups: []Matchup{
+			Matchup{Week: 1},
+			Matchup{Week: 2, Teams: []Team{
+				Team{TeamPoints: Points{TotalStr: "7"}},
+			}},
+		},
+	}
+	content := &FantasyContent{
+		Team: bye,
+		League: League{
+			Scoreboard: Scoreboard{
+				Matchups: []Matchup{
+					Matchup{Week: 1},
+					Matchup{Week: 2, Teams: []Team{bye}},
+				},
+			},
+		},
+	}
+
+	fixContent(content)
+
+	assertFloatEquals(t, 12.5, content.Team.TeamPoints.Total)
+	assertFloatEquals(t, 7, content.Team.Matchups[1].Teams[0].TeamPoints.Total)
+	assertFloatEquals(
+		t,
+		12.5,
+		content.League.Scoreboard.Matchups[1].Teams[0].TeamPoints.Total)
+}
+
+func TestFixContentNil(t *testing.T) {
+	if fixContent(nil) != nil {
+		t.Fatalf("expected nil content")
+	}
+}
+
+func TestGetFantasyContentByeWeek(t *testing.T) {
+	response := mockResponse(`<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content>
+  <league>
+    <league_key>223.l.431</league_key>
+    <scoreboard>
+      <matchups>
+        <matchup>
+          <week>3</week>
+          <teams>
+            <team>
+              <team_key>223.l.431.t.1</team_key>
+              <team_points><total>98.25</total></team_points>
+              <team_standings><rank>2</rank></team_standings>
+            </team>
+          </teams>
+        </matchup>
+        <matchup>
+          <week>3</week>
+        </matchup>
+      </matchups>
+    </scoreboard>
+  </league>
+</fantasy_content>`)
+	provider := &xmlContentProvider{
+		client: &countingHTTPApiClient{
+			client: &mockHTTPClient{Response: response},
+		},
+	}
+
+	content, err := provider.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	matchups := content.League.Scoreboard.Matchups
+	assertIntEquals(t, 2, len(matchups))
+	assertIntEquals(t, 1, len(matchups[0].Teams))
+	assertIntEquals(t, 0, len(matchups[1].Teams))
+	assertFloatEquals(t, 98.25, matchups[0].Teams[0].TeamPoints.Total)
+	assertIntEquals(t, 2, matchups[0].Teams[0].TeamStandings.Rank)
+}
+
+func TestUnmarshalPoints(t *testing.T) {
+	tests := []struct {
+		xml      string
+		expected float64
+	}{
+		{"<points><total>12.75</total></points>", 12.75},
+		{"<points><total>-3</total></points>", -3},
+		{"<points><total/></points>", 0},
+		{"<points><total>-</total></points>", 0},
+		{"<points></points>", 0},
+	}
+	for _, test := range tests {
+		var points Points
+		if err := xml.Unmarshal([]byte(test.xml), &points); err != nil {
+			t.Fatalf("error unmarshalling %s: %s", test.xml, err)
+		}
+		assertFloatEquals(t, test.expected, points.Total)
+	}
+}
+
+func TestUnmarshalTeamStandings(t *testing.T) {
+	tests := []struct {
+		xml      string
+		expected int
+	}{
+		{"<team_standings><rank>4</rank></team_standings>", 4},
+		{"<team_standings><rank/></team_standings>", 0},
+		{"<team_standings><rank>first</rank></team_standings>", 0},
+	}
+	for _, test := range tests {
+		var standings TeamStandings
+		if err := xml.Unmarshal([]byte(test.xml), &standings); err != nil {
+			t.Fatalf("error unmarshalling %s: %s", test.xml, err)
+		}
+		assertIntEquals(t, test.expected, standings.Rank)
+	}
+}
+
+func TestMarshalPoints(t *testing.T) {
+	tests := []struct {
+		points   Points
+		expected string
+	}{
+		{Points{Total: 12.75}, "<total>12.75</total>"},
+		{Points{TotalStr: "12.750"}, "<total>12.750</total>"},
+		{Points{Total: 12.75, TotalStr: "12.750"}, "<total>12.750</total>"},
+		{Points{Total: 3, TotalStr: "12.75"}, "<total>3</total>"},
+		{Points{TotalStr: "-"}, "<total>-</total>"},
+		{Points{}, "<total></total>"},
+	}
+	for _, test := range tests {
+		bits, err := xml.Marshal(test.points)
+		if err != nil {
+			t.Fatalf("error marshalling %+v: %s", test.points, err)
+		}
+		if !strings.Contains(string(bits), test.expected) {
+			t.Fatalf("Unexpected XML for %+v\n\texpected: %s\n\tactual: %s",
+				test.points,
+				test.expected,
+				bits)
+		}
+		if strings.Contains(string(bits), "<Total>") {
+			t.Fatalf("Derived field encoded: %s", bits)
+		}
+	}
+}
+
+func TestMarshalTeamStandings(t *testing.T) {
+	tests := []struct {
+		standings TeamStandings
+		expected  string
+	}{
+		{TeamStandings{Rank: 4}, "<rank>4</rank>"},
+		{TeamStandings{Rank: 4, RankStr: "4"}, "<rank>4</rank>"},
+		{TeamStandings{Rank: 2, RankStr: "4"}, "<rank>2</rank>"},
+		{TeamStandings{}, "<rank></rank>"},
+	}
+	for _, test := range tests {
+		bits, err := xml.Marshal(test.standings)
+		if err != nil {
+			t.Fatalf("error marshalling %+v: %s", test.standings, err)
+		}
+		if !strings.Contains(string(bits), test.expected) {
+			t.Fatalf("Unexpected XML for %+v\n\texpected: %s\n\tactual: %s",
+				test.standings,
+				test.expected,
+				bits)
+		}
+		if strings.Contains(string(bits), "<Rank>") {
+			t.Fatalf("Derived field encoded: %s", bits)
+		}
+	}
+}
+
+func TestMarshalContentRoundTrip(t *testing.T) {
+	team := expectedTeam
+	team.TeamPoints = Points{CoverageType: "week", Week: 3, Total: 98.5}
+	team.TeamStandings = TeamStandings{
+		Rank:   2,
+		Record: Record{Wins: 3, Losses: 1},
+	}
+	team.Matchups = []Matchup{Matchup{Week: 3, Teams: []Team{team}}}
+	content := &FantasyContent{
+		League: League{
+			LeagueKey: "223.l.431",
+			Teams:     []Team{team},
+			Standings: []Team{team},
+		},
+		Team: team,
+	}
+
+	bits, err := xml.Marshal(content)
+	if err != nil {
+		t.Fatalf("error marshalling content: %s", err)
+	}
+	var decoded FantasyContent
+	if err := xml.Unmarshal(bits, &decoded); err != nil {
+		t.Fatalf("error unmarshalling content: %s", err)
+	}
+
+	assertFloatEquals(t, 98.5, decoded.Team.TeamPoints.Total)
+	assertStringEquals(t, "98.5", decoded.Team.TeamPoints.TotalStr)
+	assertIntEquals(t, 2, decoded.League.Standings[0].TeamStandings.Rank)
+	assertIntEquals(t, 3, decoded.Team.TeamStandings.Record.Wins)
+	assertFloatEquals(
+		t,
+		98.5,
+		decoded.Team.Matchups[0].Teams[0].TeamPoints.Total)
+
+	// Encoding the decoded content is unchanged
+	reencoded, err := xml.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("error marshalling decoded content: %s", err)
+	}
+	if string(bits) != string(reencoded) {
+		t.Fatalf("Content changed after round trip\n\tfirst: %s\n\tsecond: %s",
+			bits,
+			reencoded)
+	}
+}
+
+func TestMarshalFixtureRoundTrip(t *testing.T) {
+	for _, fixture := range []string{teamXMLContent, leagueXMLContent} {
+		var content FantasyContent
+		if err := xml.Unmarshal([]byte(fixture), &content); err != nil {
+			t.Fatalf("error unmarshalling fixture: %s", err)
+		}
+		bits, err := xml.Marshal(&content)
+		if err != nil {
+			t.Fatalf("error marshalling content: %s", err)
+		}
+		var decoded FantasyContent
+		if err := xml.Unmarshal(bits, &decoded); err != nil {
+			t.Fatalf("error unmarshalling content: %s", err)
+		}
+		if !reflect.DeepEqual(content, decoded) {
+			t.Fatalf("Unexpected content\n\texpected: %+v\n\tactual: %+v",
+				content,
+				decoded)
+		}
+	}
+}
+
+var updateGolden = flag.Bool(
+	"update",
+	false,
+	"update the golden files in testdata with the actual output")
+
+func TestMarshalJSONGolden(t *testing.T) {
+	tests := []struct {
+		golden  string
+		content string
+	}{
+		{"team.json", teamXMLContent},
+		{"league.json", leagueXMLContent},
+		{"standings.json", standingsXMLContent},
+		{"roster.json", rosterXMLContent},
+	}
+	for _, test := range tests {
+		content, err := (&xmlContentProvider{
+			client: &countingHTTPApiClient{
+				client: &mockHTTPClient{Response: mockResponse(test.content)},
+			},
+		}).Get("http://example.com")
+		if err != nil {
+			t.Fatalf("error decoding content for %s: %s", test.golden, err)
+		}
+
+		actual, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			t.Fatalf("error encoding content for %s: %s", test.golden, err)
+		}
+		assertGolden(t, test.golden, actual)
+
+		for _, key := range []string{"XMLName", "TotalStr", "RankStr", "Total"} {
+			if strings.Contains(string(actual), `"`+key+`"`) {
+				t.Fatalf("Unexpected key %s in %s:\n%s", key, test.golden, actual)
+			}
+		}
+
+		// Decoding and encoding the JSON again is stable
+		var decoded FantasyContent
+		if err := json.Unmarshal(actual, &decoded); err != nil {
+			t.Fatalf("error decoding JSON for %s: %s", test.golden, err)
+		}
+		reencoded, err := json.MarshalIndent(&decoded, "", "  ")
+		if err != nil {
+			t.Fatalf("error encoding decoded JSON for %s: %s", test.golden, err)
+		}
+		assertStringEquals(t, string(actual), string(reencoded))
+	}
+}
+
+func TestMarshalJSONNumbers(t *testing.T) {
+	team := Team{
+		TeamPoints:    Points{TotalStr: "98.50"},
+		TeamStandings: TeamStandings{RankStr: "3"},
 	}
+	content := fixContent(&FantasyContent{Team: team})
 
-	if team.Players[0].PlayerPoints.Total != expectedPoints {
-		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %f\n\t"+
-			"expected: %f",
-			"Players.PlayerPoints.Total",
-			team.Players[0].PlayerPoints.Total,
-			expectedPoints)
+	bits, err := json.Marshal(content.Team)
+	if err != nil {
+		t.Fatalf("error encoding team: %s", err)
+	}
+	var actual struct {
+		TeamPoints struct {
+			Total interface{} `json:"total"`
+		} `json:"team_points"`
+		TeamStandings struct {
+			Rank interface{} `json:"rank"`
+		} `json:"team_standings"`
+	}
+	if err := json.Unmarshal(bits, &actual); err != nil {
+		t.Fatalf("error decoding team: %s", err)
 	}
+	if total, ok := actual.TeamPoints.Total.(float64); !ok || total != 98.5 {
+		t.Fatalf("Unexpected total: %#v", actual.TeamPoints.Total)
+	}
+	if rank, ok := actual.TeamStandings.Rank.(float64); !ok || rank != 3 {
+		t.Fatalf("Unexpected rank: %#v", actual.TeamStandings.Rank)
+	}
+}
 
-	if team.TeamStandings.Rank != expectedRank {
-		t.Fatalf("Fantasy content not fixed for %s\n\tactual: %d\n\t"+
-			"expected: %d",
-			"TeamStandings.Rank",
-			team.TeamStandings.Rank,
-			expectedRank)
+// assertGolden compares the actual output to the golden file in testdata,
+// updating the file instead when the -update flag is set.
+func assertGolden(t *testing.T, name string, actual []byte) {
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, append(actual, '\n'), 0644); err != nil {
+			t.Fatalf("error updating golden file %s: %s", path, err)
+		}
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading golden file %s: %s", path, err)
+	}
+	if string(expected) != string(actual)+"\n" {
+		t.Fatalf("Output differs from %s, run with -update to accept it\n"+
+			"\texpected: %s\n\tactual: %s",
+			path,
+			expected,
+			actual)
 	}
 }
 
+func FuzzFixContent(f *testing.F) {
+	f.Add(`<fantasy_content><team><matchups><matchup/></matchups></team>` +
+		`</fantasy_content>`)
+	f.Add(`<fantasy_content><league><scoreboard><matchups><matchup><teams>` +
+		`<team><team_points><total>1.5</total></team_points></team>` +
+		`</teams></matchup></matchups></scoreboard></league></fantasy_content>`)
+	f.Add(`<fantasy_content><team><team_standings><rank>x</rank>` +
+		`</team_standings></team></fantasy_content>`)
+	f.Fuzz(func(t *testing.T, content string) {
+		var fantasyContent FantasyContent
+		if err := xml.Unmarshal([]byte(content), &fantasyContent); err != nil {
+			return
+		}
+		fixContent(&fantasyContent)
+	})
+}
+
 type mockReaderCloser struct {
 	Reader    io.Reader
 	ReadError error
@@ -815,6 +2108,67 @@ func TestGetFantasyContentRequestcount(t *testing.T) {
 	}
 }
 
+func TestClientBaseURL(t *testing.T) {
+	calls := map[string]func(c *Client){
+		"GetUserLeagues":  func(c *Client) { c.GetUserLeagues("2013") },
+		"GetPlayersStats": func(c *Client) { c.GetPlayersStats("1.l.1", 1, nil) },
+		"GetPlayerDetails": func(c *Client) {
+			c.GetPlayerDetails("1.l.1", []string{"1.p.1"})
+		},
+		"GetTeamRoster":      func(c *Client) { c.GetTeamRoster("1.l.1.t.1", 1) },
+		"GetLeagueStandings": func(c *Client) { c.GetLeagueStandings("1.l.1") },
+		"GetAllTeamStats":    func(c *Client) { c.GetAllTeamStats("1.l.1", 1) },
+		"GetTeam":            func(c *Client) { c.GetTeam("1.l.1.t.1") },
+		"GetLeagueMetadata":  func(c *Client) { c.GetLeagueMetadata("1.l.1") },
+		"GetLeagueSettings":  func(c *Client) { c.GetLeagueSettings("1.l.1") },
+		"GetAllTeams":        func(c *Client) { c.GetAllTeams("1.l.1") },
+		"GetMatchupsForWeekRange": func(c *Client) {
+			c.GetMatchupsForWeekRange("1.l.1", 1, 2)
+		},
+		"GetTeamMatchupsForWeeks": func(c *Client) {
+			c.GetTeamMatchupsForWeeks("1.l.1.t.1", []int{1})
+		},
+		"GetPlayersProjections": func(c *Client) {
+			c.GetPlayersProjections("1.l.1", 1, []string{"1.p.1"})
+		},
+		"GetPlayersStatsHistory": func(c *Client) {
+			c.GetPlayersStatsHistory(
+				&League{
+					LeagueKey:   "1.l.1",
+					StartWeek:   1,
+					CurrentWeek: 1,
+					EndWeek:     1,
+				},
+				[]string{"1.p.1"})
+		},
+	}
+	for name, call := range calls {
+		provider := &mockedContentProvider{content: &FantasyContent{}}
+		client := &Client{Provider: provider}
+
+		call(client)
+		if !strings.HasPrefix(provider.lastGetURL, YahooBaseURL+"/") {
+			t.Fatalf("%s did not use the default base URL: %s",
+				name,
+				provider.lastGetURL)
+		}
+
+		client.BaseURL = "http://localhost:8080/fantasy/v2"
+		call(client)
+		if !strings.HasPrefix(provider.lastGetURL, client.BaseURL+"/") {
+			t.Fatalf("%s did not use the client's base URL: %s",
+				name,
+				provider.lastGetURL)
+		}
+	}
+}
+
+func TestYahooBaseURLUsesHTTPS(t *testing.T) {
+	if !strings.HasPrefix(YahooBaseURL, "https://") {
+		t.Fatalf("YahooBaseURL does not use HTTPS: %s", YahooBaseURL)
+	}
+}
+
 //
 // Test GetUserLeagues
 //
@@ -1109,6 +2463,522 @@ func TestGetPlayerStatsParams(t *testing.T) {
 	assertURLContainsParam(t, provider.lastGetURL, "week", fmt.Sprintf("%d", week))
 }
 
+//
+// Test GetPlayerDetails
+//
+
+func TestGetPlayerDetails(t *testing.T) {
+	client := &Client{
+		Provider: &xmlContentProvider{
+			client: &countingHTTPApiClient{
+				client: &mockHTTPClient{
+					Response: mockResponse(playerDetailsXMLContent),
+				},
+			},
+		},
+	}
+
+	players, err := client.GetPlayerDetails("223.l.431", []string{"223.p.8261"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+	if len(players) != 1 {
+		t.Fatalf("Unexpected players: %+v", players)
+	}
+	player := players[0]
+	assertStringEquals(t, "223.p.8261", player.PlayerKey)
+	assertStringEquals(t, "Q", player.Status)
+	assertStringEquals(t, "Questionable", player.StatusFull)
+	assertStringEquals(t, "Hamstring", player.InjuryNote)
+	assertStringEquals(t, "nfl.t.7", player.EditorialTeamKey)
+	assertStringEquals(t, "Denver Broncos", player.EditorialTeamFullName)
+	assertStringEquals(t, "Den", player.EditorialTeamAbbr)
+	if !reflect.DeepEqual([]int{9}, player.ByeWeeks) {
+		t.Fatalf("Unexpected bye weeks: %+v", player.ByeWeeks)
+	}
+	assertStringEquals(t, "18", player.UniformNumber)
+	assertStringEquals(t, "https://example.com/headshot.png", player.Headshot.URL)
+	assertStringEquals(t, "small", player.Headshot.Size)
+	assertStringEquals(t, "https://example.com/image.png", player.ImageURL)
+	if !player.IsUndroppable {
+		t.Fatalf("Player should be undroppable")
+	}
+	assertStringEquals(t, "O", player.PositionType)
+	assertStringEquals(t, "week", player.PercentOwned.CoverageType)
+	assertIntEquals(t, 3, player.PercentOwned.Week)
+	assertFloatEquals(t, 97.5, player.PercentOwned.Value)
+	assertFloatEquals(t, -1.5, player.PercentOwned.Delta)
+	assertFloatEquals(t, 88, player.PercentStarted.Value)
+	assertFloatEquals(t, 2, player.PercentStarted.Delta)
+	assertStringEquals(t, "team", player.Ownership.OwnershipType)
+	assertStringEquals(t, "223.l.431.t.1", player.Ownership.OwnerTeamKey)
+	assertStringEquals(t, "Team Name", player.Ownership.OwnerTeamName)
+}
+
+func TestGetPlayerDetailsError(t *testing.T) {
+	client := mockClient(&FantasyContent{}, errors.New("error"))
+
+	_, err := client.GetPlayerDetails("223.l.431", []string{"223.p.8261"})
+	if err == nil {
+		t.Fatalf("Client did not return error")
+	}
+}
+
+func TestGetPlayerDetailsBatchesPlayers(t *testing.T) {
+	var groups []string
+	client := New(mockHTTPClientFunc(func(url string) (*http.Response, error) {
+		keys := url[strings.Index(url, "player_keys=")+len("player_keys="):]
+		groups = append(groups, keys[:strings.IndexAny(keys, "/;")])
+		return mockResponse(`<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content><league><players/></league></fantasy_content>`), nil
+	}))
+	playerKeys := make([]string, 26)
+	for i := range playerKeys {
+		playerKeys[i] = fmt.Sprintf("223.p.%d", i+1)
+	}
+
+	_, err := client.GetPlayerDetails("223.l.431", playerKeys)
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	assertIntEquals(t, 2, len(groups))
+	assertStringEquals(t, strings.Join(playerKeys[:25], ","), groups[0])
+	assertStringEquals(t, "223.p.26", groups[1])
+}
+
+func TestGetPlayerDetailsParams(t *testing.T) {
+	provider := &mockedContentProvider{content: &FantasyContent{}}
+	client := &Client{Provider: provider}
+
+	client.GetPlayerDetails("223.l.431", []string{"223.p.8261", "223.p.1"})
+
+	assertURLContainsParam(
+		t,
+		provider.lastGetURL,
+		"player_keys",
+		"223.p.8261,223.p.1")
+	assertURLContainsParam(
+		t,
+		provider.lastGetURL,
+		"out",
+		"percent_owned,percent_started,ownership")
+}
+
+func TestPlayerElligiblePositions(t *testing.T) {
+	var player Player
+	err := xml.Unmarshal([]byte(`
+      <player>
+        <player_key>223.p.8261</player_key>
+        <eligible_positions>
+          <position>WR</position>
+          <position>W/R/T</position>
+        </eligible_positions>
+      </player>`), &player)
+	if err != nil {
+		t.Fatalf("error decoding player: %s", err)
+	}
+
+	expected := []string{"WR", "W/R/T"}
+	if !reflect.DeepEqual(expected, player.ElligiblePositions) {
+		t.Fatalf("Unexpected positions\n\texpected: %+v\n\tactual: %+v",
+			expected,
+			player.ElligiblePositions)
+	}
+}
+
+var playerDetailsXMLContent = `<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <league>
+    <league_key>223.l.431</league_key>
+    <players count="1">
+      <player>
+        <player_key>223.p.8261</player_key>
+        <player_id>8261</player_id>
+        <name>
+          <full>Firstname Lastname</full>
+          <first>Firstname</first>
+          <last>Lastname</last>
+        </name>
+        <status>Q</status>
+        <status_full>Questionable</status_full>
+        <injury_note>Hamstring</injury_note>
+        <editorial_player_key>nfl.p.8261</editorial_player_key>
+        <editorial_team_key>nfl.t.7</editorial_team_key>
+        <editorial_team_full_name>Denver Broncos</editorial_team_full_name>
+        <editorial_team_abbr>Den</editorial_team_abbr>
+        <bye_weeks>
+          <week>9</week>
+        </bye_weeks>
+        <uniform_number>18</uniform_number>
+        <display_position>QB</display_position>
+        <headshot>
+          <url>https://example.com/headshot.png</url>
+          <size>small</size>
+        </headshot>
+        <image_url>https://example.com/image.png</image_url>
+        <is_undroppable>1</is_undroppable>
+        <position_type>O</position_type>
+        <eligible_positions>
+          <position>QB</position>
+        </eligible_positions>
+        <percent_owned>
+          <coverage_type>week</coverage_type>
+          <week>3</week>
+          <value>97.5</value>
+          <delta>-1.5</delta>
+        </percent_owned>
+        <percent_started>
+          <coverage_type>week</coverage_type>
+          <week>3</week>
+          <value>88</value>
+          <delta>2</delta>
+        </percent_started>
+        <ownership>
+          <ownership_type>team</ownership_type>
+          <owner_team_key>223.l.431.t.1</owner_team_key>
+          <owner_team_name>Team Name</owner_team_name>
+        </ownership>
+      </player>
+    </players>
+  </league>
+</fantasy_content>`
+
+//
+// Test GetPlayersProjections
+//
+
+func TestGetPlayersProjections(t *testing.T) {
+	players := []Player{
+		Player{
+			PlayerKey:    "223.p.8261",
+			PlayerPoints: Points{CoverageType: "week", Week: 5, Total: 17.5},
+		},
+	}
+	client := mockClient(&FantasyContent{League: League{Players: players}}, nil)
+
+	actual, err := client.GetPlayersProjections("223.l.431", 5, []string{"223.p.8261"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+	assertPlayersEqual(t, &players[0], &actual[0])
+	assertFloatEquals(t, 17.5, actual[0].PlayerPoints.Total)
+}
+
+func TestGetPlayersProjectionsError(t *testing.T) {
+	client := mockClient(&FantasyContent{}, errors.New("error"))
+
+	_, err := client.GetPlayersProjections("223.l.431", 5, []string{"223.p.8261"})
+	if err == nil {
+		t.Fatalf("Client did not return error")
+	}
+}
+
+func TestGetPlayersProjectionsBatchesPlayers(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+	playerKeys := make([]string, 30)
+	for i := range playerKeys {
+		playerKeys[i] = fmt.Sprintf("223.p.%d", i+1)
+	}
+
+	players, err := client.GetPlayersProjections("223.l.431", 5, playerKeys)
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	assertIntEquals(t, 30, len(players))
+	assertStringEquals(t, "223.p.30", players[29].PlayerKey)
+	assertIntEquals(t, 2, requests)
+}
+
+func TestGetPlayersProjectionsNoPlayers(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+
+	players, err := client.GetPlayersProjections("223.l.431", 5, nil)
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+	assertIntEquals(t, 0, len(players))
+	assertIntEquals(t, 0, requests)
+}
+
+func TestGetPlayersProjectionsParams(t *testing.T) {
+	provider := &mockedContentProvider{content: &FantasyContent{}}
+	client := &Client{Provider: provider}
+
+	client.GetPlayersProjections("223.l.431", 5, []string{"223.p.8261"})
+
+	assertURLContainsParam(t, provider.lastGetURL, "player_keys", "223.p.8261")
+	assertURLContainsParam(t, provider.lastGetURL, "type", "projected_week")
+	assertURLContainsParam(t, provider.lastGetURL, "week", "5")
+}
+
+//
+// Test GetPlayersStatsHistory
+//
+
+func TestGetPlayersStatsHistory(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+	league := &League{
+		LeagueKey:   "223.l.431",
+		StartWeek:   1,
+		CurrentWeek: 3,
+		EndWeek:     16,
+	}
+
+	history, err := client.GetPlayersStatsHistory(
+		league,
+		[]string{"223.p.1", "223.p.2"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	assertIntEquals(t, 2, len(history))
+	for _, playerKey := range []string{"223.p.1", "223.p.2"} {
+		weeks := history[playerKey]
+		assertIntEquals(t, 3, len(weeks))
+		for i, week := range weeks {
+			assertIntEquals(t, i+1, week.Week)
+			assertFloatEquals(t, float64(10*(i+1)), week.Points.Total)
+			assertIntEquals(t, 1, len(week.Stats))
+			assertStringEquals(t, fmt.Sprintf("%d", i+1), week.Stats[0].Value)
+		}
+	}
+	assertIntEquals(t, 3, requests)
+}
+
+func TestGetPlayersStatsHistoryFinishedLeague(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+	league := &League{
+		LeagueKey:   "223.l.431",
+		StartWeek:   2,
+		CurrentWeek: 4,
+		EndWeek:     4,
+		IsFinished:  true,
+	}
+
+	history, err := client.GetPlayersStatsHistory(league, []string{"223.p.1"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	weeks := history["223.p.1"]
+	assertIntEquals(t, 3, len(weeks))
+	assertIntEquals(t, 2, weeks[0].Week)
+	assertIntEquals(t, 4, weeks[2].Week)
+}
+
+func TestGetPlayersStatsHistoryCached(t *testing.T) {
+	requests := 0
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	client := New(mockHistoryHTTPClient(&requests), WithCache(cache))
+	league := &League{
+		LeagueKey:   "223.l.431",
+		StartWeek:   1,
+		CurrentWeek: 3,
+		EndWeek:     16,
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetPlayersStatsHistory(league, []string{"223.p.1"})
+		if err != nil {
+			t.Fatalf("Client returned unexpected error: %s", err)
+		}
+	}
+	assertIntEquals(t, 3, requests)
+
+	// Only the current week is cached for the current cache period
+	entries := cache.Entries("")
+	assertIntEquals(t, 3, len(entries))
+	for _, entry := range entries {
+		current := strings.Contains(entry.URL, "week=3")
+		if entry.Final == current {
+			t.Fatalf("Unexpected final %t for %s", entry.Final, entry.URL)
+		}
+		if entry.Final != entry.Time.IsZero() {
+			t.Fatalf("Unexpected cache time %s for %s", entry.Time, entry.URL)
+		}
+	}
+}
+
+func TestGetPlayersStatsHistoryCachedWithoutFinalCache(t *testing.T) {
+	requests := 0
+	cache := mockCache()
+	client := New(mockHistoryHTTPClient(&requests), WithCache(cache))
+	league := &League{
+		LeagueKey:  "223.l.431",
+		StartWeek:  1,
+		EndWeek:    2,
+		IsFinished: true,
+	}
+
+	start := time.Now()
+	_, err := client.GetPlayersStatsHistory(league, []string{"223.p.1"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	// Weeks that no longer change are cached for the current cache period
+	if !strings.Contains(cache.lastSetURL, "week=2") ||
+		cache.lastSetTime.Before(start) {
+
+		t.Fatalf("Unexpected cache time %s for %s",
+			cache.lastSetTime,
+			cache.lastSetURL)
+	}
+	if cache.lastGetTime.Before(start) {
+		t.Fatalf("Unexpected lookup time %s", cache.lastGetTime)
+	}
+}
+
+func TestGetPlayersStatsHistoryBatchesPlayers(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+	league := &League{
+		LeagueKey:   "223.l.431",
+		StartWeek:   1,
+		CurrentWeek: 2,
+		EndWeek:     16,
+	}
+	playerKeys := make([]string, 30)
+	for i := range playerKeys {
+		playerKeys[i] = fmt.Sprintf("223.p.%d", i+1)
+	}
+
+	history, err := client.GetPlayersStatsHistory(league, playerKeys)
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+
+	assertIntEquals(t, 30, len(history))
+	assertIntEquals(t, 2, len(history["223.p.30"]))
+	// Two groups of players for each week
+	assertIntEquals(t, 4, requests)
+}
+
+func TestGetPlayersStatsHistoryNoPlayers(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+
+	_, err := client.GetPlayersStatsHistory(
+		&League{
+			LeagueKey:   "223.l.431",
+			StartWeek:   1,
+			CurrentWeek: 3,
+			EndWeek:     16,
+		},
+		nil)
+	if err == nil {
+		t.Fatalf("Client did not return error")
+	}
+	assertIntEquals(t, 0, requests)
+}
+
+func TestGetPlayersStatsHistoryError(t *testing.T) {
+	client := New(mockHTTPClientFunc(func(url string) (*http.Response, error) {
+		return nil, errors.New("error")
+	}))
+
+	_, err := client.GetPlayersStatsHistory(
+		&League{
+			LeagueKey:   "223.l.431",
+			StartWeek:   1,
+			CurrentWeek: 3,
+			EndWeek:     16,
+		},
+		[]string{"223.p.1"})
+	if err == nil {
+		t.Fatalf("Client did not return error")
+	}
+}
+
+func TestGetPlayersStatsHistoryMissingWeeks(t *testing.T) {
+	leagues := map[string]*League{
+		"no end week": &League{
+			LeagueKey:   "223.l.431",
+			StartWeek:   1,
+			CurrentWeek: 3,
+		},
+		"no current week": &League{
+			LeagueKey: "223.l.431",
+			StartWeek: 1,
+			EndWeek:   16,
+		},
+	}
+	for name, league := range leagues {
+		requests := 0
+		client := New(mockHistoryHTTPClient(&requests))
+
+		_, err := client.GetPlayersStatsHistory(league, []string{"223.p.1"})
+		if err == nil {
+			t.Fatalf("Client did not return error for league with %s", name)
+		}
+		assertIntEquals(t, 0, requests)
+	}
+}
+
+func TestGetPlayersStatsHistoryFinishedLeagueWithoutCurrentWeek(t *testing.T) {
+	requests := 0
+	client := New(mockHistoryHTTPClient(&requests))
+	league := &League{
+		LeagueKey:  "223.l.431",
+		StartWeek:  1,
+		EndWeek:    2,
+		IsFinished: true,
+	}
+
+	history, err := client.GetPlayersStatsHistory(league, []string{"223.p.1"})
+	if err != nil {
+		t.Fatalf("Client returned unexpected error: %s", err)
+	}
+	assertIntEquals(t, 2, len(history["223.p.1"]))
+	assertIntEquals(t, 2, requests)
+}
+
+// mockHistoryHTTPClient returns a client serving the weekly stats of the
+// requested players, where each player scores ten points per week number,
+// counting the requests.
+func mockHistoryHTTPClient(requests *int) HTTPClient {
+	return mockHTTPClientFunc(func(url string) (*http.Response, error) {
+		*requests++
+
+		var week int
+		fmt.Sscanf(url[strings.Index(url, "week=")+len("week="):], "%d", &week)
+		keys := url[strings.Index(url, "player_keys=")+len("player_keys="):]
+		keys = keys[:strings.Index(keys, "/")]
+
+		players := ""
+		for _, key := range strings.Split(keys, ",") {
+			players += fmt.Sprintf(`
+      <player>
+        <player_key>%s</player_key>
+        <player_stats>
+          <coverage_type>week</coverage_type>
+          <week>%d</week>
+          <stats><stat><stat_id>4</stat_id><value>%d</value></stat></stats>
+        </player_stats>
+        <player_points>
+          <coverage_type>week</coverage_type>
+          <week>%d</week>
+          <total>%d</total>
+        </player_points>
+      </player>`, key, week, week, week, 10*week)
+		}
+		return mockResponse(`<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content>
+  <league>
+    <league_key>223.l.431</league_key>
+    <players>` + players + `
+    </players>
+  </league>
+</fantasy_content>`), nil
+	})
+}
+
 //
 // Test GetTeamRoster
 //
@@ -1355,6 +3225,16 @@ func TestGetMatchupsForWeekRangeError(t *testing.T) {
 	}
 }
 
+func TestGetTeamMatchupsForWeeksRequestsEachWeekOnce(t *testing.T) {
+	provider := &mockedContentProvider{content: &FantasyContent{}}
+	client := &Client{Provider: provider}
+	client.GetTeamMatchupsForWeeks("teamKey", []int{1, 2})
+
+	if !strings.HasSuffix(provider.lastGetURL, "/matchups;weeks=1,2") {
+		t.Fatalf("Did not generate proper request: %s", provider.lastGetURL)
+	}
+}
+
 //
 // Assert
 //
@@ -1380,6 +3260,14 @@ func assertURLContainsParam(t *testing.T, url string, param string, value string
 	}
 }
 
+func assertURLHasPrefix(t *testing.T, prefix string, url string) {
+	if !strings.HasPrefix(url, prefix) {
+		t.Fatalf("Unexpected URL\n\texpected prefix: %s\n\tactual: %s",
+			prefix,
+			url)
+	}
+}
+
 func assertTeamsEqual(t *testing.T, expectedTeam *Team, actualTeam *Team) {
 	assertStringEquals(t, expectedTeam.TeamKey, actualTeam.TeamKey)
 	assertUintEquals(t, expectedTeam.TeamID, actualTeam.TeamID)
@@ -1454,6 +3342,16 @@ func assertIntEquals(t *testing.T, expected int, actual int) {
 	}
 }
 
+func assertInt64Equals(t *testing.T, expected int64, actual int64) {
+	if actual != expected {
+		t.Fatalf("Unexpected content\n"+
+			"\tactual: %d\n"+
+			"\texpected: %d",
+			actual,
+			expected)
+	}
+}
+
 func assertBoolEquals(t *testing.T, expected bool, actual bool) {
 	if actual != expected {
 		t.Fatalf("Unexpected content\n"+
@@ -1509,6 +3407,23 @@ func (m *mockedContentProvider) RequestCount() int {
 	return m.count
 }
 
+// mockedRevalidatingContentProvider creates a
+// goff.revalidatingContentProvider that returns the given content and error
+// whenever Provider.Get or Provider.revalidate is called.
+type mockedRevalidatingContentProvider struct {
+	mockedContentProvider
+	lastRevalidated *FantasyContent
+}
+
+func (m *mockedRevalidatingContentProvider) revalidate(
+	url string,
+	content *FantasyContent,
+	t trace) (*FantasyContent, error) {
+
+	m.lastRevalidated = content
+	return m.Get(url)
+}
+
 type mockedCache struct {
 	data           map[string](*FantasyContent)
 	lastSetURL     string
@@ -1517,11 +3432,15 @@ type mockedCache struct {
 
 	lastGetURL  string
 	lastGetTime time.Time
+
+	stale       map[string](*FantasyContent)
+	staleServed int
 }
 
 func mockCache() *mockedCache {
 	return &mockedCache{
-		data: make(map[string](*FantasyContent)),
+		data:  make(map[string](*FantasyContent)),
+		stale: make(map[string](*FantasyContent)),
 	}
 }
 
@@ -1542,11 +3461,28 @@ func (c *mockedCache) Get(
 	return content, ok
 }
 
+func (c *mockedCache) GetStale(
+	url string,
+	time time.Time) (content *FantasyContent, ok bool) {
+
+	c.staleServed++
+	return c.PeekStale(url, time)
+}
+
+func (c *mockedCache) PeekStale(
+	url string,
+	time time.Time) (content *FantasyContent, ok bool) {
+
+	content, ok = c.stale[url]
+	return content, ok
+}
+
 type mockHTTPClient struct {
-	Response   *http.Response
-	Error      error
-	ErrorCount int
-	LastURL    string
+	Response    *http.Response
+	Error       error
+	ErrorCount  int
+	LastURL     string
+	LastRequest *http.Request
 
 	RequestCount int
 }
@@ -1561,6 +3497,23 @@ func (m *mockHTTPClient) Get(url string) (*http.Response, error) {
 	return m.Response, err
 }
 
+func (m *mockHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	m.LastRequest = request
+	return m.Get(request.URL.String())
+}
+
+// mockHTTPClientFunc implements HTTPClient using a function
+type mockHTTPClientFunc func(url string) (*http.Response, error)
+
+func (f mockHTTPClientFunc) Get(url string) (*http.Response, error) {
+	return f(url)
+}
+
+// mockGetHTTPClient hides all methods of a HTTPClient other than Get
+type mockGetHTTPClient struct {
+	HTTPClient
+}
+
 //
 // Test Data
 //
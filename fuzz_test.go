@@ -0,0 +1,154 @@
+package goff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+//
+// Fuzz decoding
+//
+
+// addContentSeeds adds the XML fixtures used by the other tests to the corpus
+func addContentSeeds(f *testing.F) {
+	f.Add(teamXMLContent)
+	f.Add(leagueXMLContent)
+	f.Add(standingsXMLContent)
+	f.Add(rosterXMLContent)
+	f.Add(leaguePlayersXMLContent(2))
+	f.Add(`<fantasy_content><league><scoreboard><matchups><matchup>` +
+		`<teams><team><team_points><total>1e400</total></team_points>` +
+		`<team_standings><rank>NaN</rank></team_standings></team></teams>` +
+		`</matchup><matchup/></matchups></scoreboard></league>` +
+		`</fantasy_content>`)
+}
+
+func FuzzXMLContentProviderGet(f *testing.F) {
+	addContentSeeds(f)
+	f.Fuzz(func(t *testing.T, content string) {
+		provider := &xmlContentProvider{
+			client: &countingHTTPApiClient{
+				client: &mockHTTPClient{Response: mockResponse(content)},
+			},
+		}
+		fantasyContent, err := provider.Get("http://example.com")
+		if err != nil {
+			if fantasyContent != nil {
+				t.Fatalf("content returned with error: %s", err)
+			}
+			return
+		}
+		assertNumbersParsed(t, fantasyContent)
+	})
+}
+
+func FuzzContentRoundTrip(f *testing.F) {
+	addContentSeeds(f)
+	f.Fuzz(func(t *testing.T, content string) {
+		var decoded FantasyContent
+		if err := xml.Unmarshal([]byte(content), &decoded); err != nil {
+			return
+		}
+		first, err := xml.Marshal(fixContent(&decoded))
+		if err != nil {
+			t.Fatalf("error encoding decoded content: %s", err)
+		}
+
+		// Encoding normalizes the content once, after which decoding and
+		// encoding again must not change it
+		var redecoded FantasyContent
+		if err := xml.Unmarshal(first, &redecoded); err != nil {
+			t.Fatalf("error decoding encoded content: %s\n%s", err, first)
+		}
+		assertNumbersParsed(t, &redecoded)
+		second, err := xml.Marshal(fixContent(&redecoded))
+		if err != nil {
+			t.Fatalf("error encoding decoded content: %s", err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Fatalf("Content changed after round trip\n\tfirst: %s\n\tsecond: %s",
+				first,
+				second)
+		}
+	})
+}
+
+//
+// Test decoded numbers
+//
+
+func TestNumbersParsedForFixtures(t *testing.T) {
+	fixtures := []string{
+		teamXMLContent,
+		leagueXMLContent,
+		standingsXMLContent,
+		rosterXMLContent,
+		leaguePlayersXMLContent(10),
+	}
+	for _, fixture := range fixtures {
+		var content FantasyContent
+		if err := xml.Unmarshal([]byte(fixture), &content); err != nil {
+			t.Fatalf("error decoding fixture: %s", err)
+		}
+		assertNumbersParsed(t, &content)
+	}
+}
+
+// assertNumbersParsed verifies every Points and TeamStandings in the content
+// has the numbers parsed from their string fields, or is left alone when the
+// strings aren't numbers.
+func assertNumbersParsed(t *testing.T, content *FantasyContent) {
+	walkContent(reflect.ValueOf(content).Elem(), func(v interface{}) {
+		switch value := v.(type) {
+		case *Points:
+			total, err := strconv.ParseFloat(value.TotalStr, 64)
+			if err != nil {
+				return
+			}
+			if value.Total != total &&
+				!(math.IsNaN(total) && math.IsNaN(value.Total)) {
+
+				t.Fatalf("Total not parsed from %q\n\texpected: %f\n\tactual: %f",
+					value.TotalStr,
+					total,
+					value.Total)
+			}
+		case *TeamStandings:
+			rank, err := strconv.ParseInt(value.RankStr, 10, 64)
+			if err != nil {
+				return
+			}
+			if value.Rank != int(rank) {
+				t.Fatalf("Rank not parsed from %q\n\texpected: %d\n\tactual: %d",
+					value.RankStr,
+					rank,
+					value.Rank)
+			}
+		}
+	})
+}
+
+// walkContent calls fn with a pointer to every exported struct reachable from v
+func walkContent(v reflect.Value, fn func(interface{})) {
+	switch v.Kind() {
+	case reflect.Struct:
+		fn(v.Addr().Interface())
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanInterface() {
+				walkContent(field, fn)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkContent(v.Index(i), fn)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkContent(v.Elem(), fn)
+		}
+	}
+}
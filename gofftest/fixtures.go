@@ -0,0 +1,243 @@
+package gofftest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/e0/goff"
+)
+
+// FixtureURLHeader is added to each fixture to record the normalized URL of
+// the request it responds to.
+const FixtureURLHeader = "X-Goff-Fixture-Url"
+
+// scrubbedHeaders are removed from fixtures, as they may identify the user
+// that made the request.
+var scrubbedHeaders = []string{"Set-Cookie", "Authorization", "Transfer-Encoding"}
+
+// unsafeFileCharacters are replaced in the names of fixture files.
+var unsafeFileCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Recorder is a goff.HTTPClient that writes each response received from
+// another HTTPClient to a fixture in a directory, so it can later be served
+// by a Replayer. OAuth tokens and signatures are scrubbed from the fixtures,
+// along with cookies.
+//
+// To record fixtures from the real API, wrap the HTTPClient authorized to use
+// it:
+//
+//    client := goff.New(gofftest.NewRecorder(httpClient, "testdata/fixtures"))
+type Recorder struct {
+	client goff.HTTPClient
+	dir    string
+}
+
+// Replayer is a goff.HTTPClient that serves the responses recorded in a
+// fixture directory by a Recorder, matching requests by their normalized URL.
+// Requests without a fixture fail.
+type Replayer struct {
+	dir string
+}
+
+// NewRecorder creates a Recorder that makes requests using the client and
+// writes the responses to the given directory, creating it if needed.
+func NewRecorder(client goff.HTTPClient, dir string) *Recorder {
+	return &Recorder{client: client, dir: dir}
+}
+
+// NewReplayer creates a Replayer serving the fixtures in the given directory.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+//
+// Recorder
+//
+
+// Get makes a GET request for the URL and records the response.
+func (r *Recorder) Get(url string) (*http.Response, error) {
+	response, err := r.client.Get(url)
+	return r.record(url, response, err)
+}
+
+// Do sends the request and records the response.
+func (r *Recorder) Do(request *http.Request) (*http.Response, error) {
+	var response *http.Response
+	var err error
+	if client, ok := r.client.(goff.HTTPRequestClient); ok {
+		response, err = client.Do(request)
+	} else if plainGet(request) {
+		response, err = r.client.Get(request.URL.String())
+	} else {
+		return nil, goff.ErrRequestUnsupported
+	}
+	return r.record(request.URL.String(), response, err)
+}
+
+// plainGet reports whether the request is a GET request without a body or
+// headers, which is all a HTTPClient without Do can send.
+func plainGet(request *http.Request) bool {
+	return (request.Method == "" || request.Method == http.MethodGet) &&
+		(request.Body == nil || request.Body == http.NoBody) &&
+		len(request.Header) == 0
+}
+
+// record writes the response for the URL to a fixture, unless the request
+// failed.
+func (r *Recorder) record(
+	url string,
+	response *http.Response,
+	err error) (*http.Response, error) {
+
+	if err != nil {
+		return response, err
+	}
+	if err := WriteFixture(r.dir, url, response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return response, nil
+}
+
+//
+// Replayer
+//
+
+// Get returns the response recorded for the URL.
+func (r *Replayer) Get(url string) (*http.Response, error) {
+	return ReadFixture(r.dir, url)
+}
+
+// Do returns the response recorded for the request's URL. Headers of the
+// request, such as those of conditional requests, are ignored.
+func (r *Replayer) Do(request *http.Request) (*http.Response, error) {
+	return ReadFixture(r.dir, request.URL.String())
+}
+
+//
+// Fixtures
+//
+
+// WriteFixture writes the response for the URL to a fixture in the directory,
+// creating it if needed. The body of the response is read and replaced, so
+// the response can still be used.
+func WriteFixture(dir string, url string, response *http.Response) error {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+	for key, values := range response.Header {
+		for _, value := range values {
+			header.Add(key, goff.Redact(value))
+		}
+	}
+	for _, key := range scrubbedHeaders {
+		header.Del(key)
+	}
+	normalized := NormalizeURL(url)
+	header.Set(FixtureURLHeader, normalized)
+	scrubbed := []byte(goff.Redact(string(body)))
+	header.Set("Content-Length", strconv.Itoa(len(scrubbed)))
+
+	fixture := &http.Response{
+		Status:        response.Status,
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(scrubbed)),
+		ContentLength: int64(len(scrubbed)),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, fixtureName(normalized)))
+	if err != nil {
+		return err
+	}
+	if err := fixture.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadFixture returns the response recorded for the URL in the directory.
+func ReadFixture(dir string, url string) (*http.Response, error) {
+	normalized := NormalizeURL(url)
+	bits, err := ioutil.ReadFile(filepath.Join(dir, fixtureName(normalized)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no fixture recorded for %s", goff.Redact(url))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.ReadResponse(
+		bufio.NewReader(bytes.NewReader(bits)),
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture for %s: %s", normalized, err)
+	}
+	if recorded := response.Header.Get(FixtureURLHeader); recorded != normalized {
+		response.Body.Close()
+		return nil, fmt.Errorf(
+			"fixture for %s was recorded for %s",
+			normalized,
+			recorded)
+	}
+	return response, nil
+}
+
+// NormalizeURL returns the path and query of the URL used to match requests
+// to fixtures. The scheme and host are ignored, OAuth parameters are removed,
+// and the remaining query parameters are sorted.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "oauth_") {
+			query.Del(key)
+		}
+	}
+
+	normalized := u.EscapedPath()
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+	return normalized
+}
+
+// fixtureName returns the name of the file storing the fixture for the
+// normalized URL, which is readable but unique.
+func fixtureName(normalized string) string {
+	name := unsafeFileCharacters.ReplaceAllString(
+		strings.TrimPrefix(normalized, BasePath),
+		"_")
+	name = strings.Trim(name, "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	hash := sha1.Sum([]byte(normalized))
+	return name + "-" + hex.EncodeToString(hash[:4]) + ".http"
+}
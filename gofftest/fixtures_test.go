@@ -0,0 +1,203 @@
+package gofftest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/e0/goff"
+)
+
+//
+// Test Recorder and Replayer
+//
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	server := NewServer(testLeague())
+	recorder := goff.New(
+		NewRecorder(server.HTTPClient(), dir),
+		goff.WithBaseURL(server.URL))
+
+	expected, err := recorder.GetTeam("314.l.431.t.1")
+	if err != nil {
+		t.Fatalf("error recording team: %s", err)
+	}
+	server.Close()
+
+	replayer := goff.New(NewReplayer(dir), goff.WithBaseURL(server.URL))
+	actual, err := replayer.GetTeam("314.l.431.t.1")
+	if err != nil {
+		t.Fatalf("error replaying team: %s", err)
+	}
+	assertStringEquals(t, expected.Name, actual.Name)
+	assertIntEquals(t, len(expected.Roster.Players), len(actual.Roster.Players))
+
+	// Replayed for any host, and repeatedly
+	replayer = goff.New(NewReplayer(dir))
+	for i := 0; i < 2; i++ {
+		if _, err := replayer.GetTeam("314.l.431.t.1"); err != nil {
+			t.Fatalf("error replaying team from a different host: %s", err)
+		}
+	}
+}
+
+func TestRecordScrubsCredentials(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+			w.Header().Set("ETag", `"abc"`)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`<fantasy_content yahoo:uri="/league?oauth_token=abc">` +
+				`</fantasy_content>`))
+		}))
+	defer server.Close()
+
+	recorder := NewRecorder(server.Client(), dir)
+	url := server.URL + "/fantasy/v2/league?oauth_token=abc&oauth_signature=def"
+	response, err := recorder.Get(url)
+	if err != nil {
+		t.Fatalf("error recording response: %s", err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	if !strings.Contains(string(body), "oauth_token=abc") {
+		t.Fatalf("recorded response modified: %s", body)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.http"))
+	if len(files) != 1 {
+		t.Fatalf("unexpected fixtures: %v", files)
+	}
+	fixture, _ := ioutil.ReadFile(files[0])
+	for _, secret := range []string{"abc&", "def", "token=abc", "session"} {
+		if strings.Contains(string(fixture), secret) {
+			t.Fatalf("fixture contains %q:\n%s", secret, fixture)
+		}
+	}
+
+	replayed, err := NewReplayer(dir).Get(
+		"https://example.com/fantasy/v2/league?oauth_signature=ghi")
+	if err != nil {
+		t.Fatalf("error replaying response: %s", err)
+	}
+	assertStringEquals(t, `"abc"`, replayed.Header.Get("ETag"))
+	assertIntEquals(t, http.StatusOK, replayed.StatusCode)
+}
+
+func TestRecordErrorStatus(t *testing.T) {
+	dir := t.TempDir()
+	server := NewServer(testLeague())
+	defer server.Close()
+	client := goff.New(
+		NewRecorder(server.HTTPClient(), dir),
+		goff.WithBaseURL(server.URL),
+		goff.WithRetryPolicy(&goff.RetryPolicy{}))
+
+	client.GetLeagueMetadata("314.l.999")
+
+	response, err := NewReplayer(dir).Get(
+		client.URL().League("314.l.999").Metadata().String())
+	if err != nil {
+		t.Fatalf("error replaying response: %s", err)
+	}
+	assertIntEquals(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestRecordUnsupportedRequest(t *testing.T) {
+	dir := t.TempDir()
+	server := NewServer(testLeague())
+	defer server.Close()
+	recorder := NewRecorder(getOnlyClient{server.HTTPClient()}, dir)
+
+	request, _ := http.NewRequest(
+		"POST",
+		server.URL+"/fantasy/v2/league/314.l.431/transactions",
+		strings.NewReader("<fantasy_content/>"))
+	if _, err := recorder.Do(request); err != goff.ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for unsupported request: %v", err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	assertIntEquals(t, 0, len(files))
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	replayer := goff.New(
+		NewReplayer(t.TempDir()),
+		goff.WithRetryPolicy(&goff.RetryPolicy{}))
+
+	if _, err := replayer.GetLeagueMetadata("314.l.431"); err == nil {
+		t.Fatalf("no error returned without a fixture")
+	}
+}
+
+func TestReplayMismatchedFixture(t *testing.T) {
+	dir := t.TempDir()
+	url := "https://example.com/fantasy/v2/league/314.l.431"
+	response := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("<fantasy_content/>")),
+	}
+	if err := WriteFixture(dir, url, response); err != nil {
+		t.Fatalf("error writing fixture: %s", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.http"))
+	fixture, _ := ioutil.ReadFile(files[0])
+	fixture = []byte(strings.Replace(
+		string(fixture),
+		"314.l.431",
+		"314.l.999",
+		-1))
+	if err := ioutil.WriteFile(files[0], fixture, 0644); err != nil {
+		t.Fatalf("error modifying fixture: %s", err)
+	}
+
+	if _, err := ReadFixture(dir, url); err == nil {
+		t.Fatalf("no error returned for a fixture recorded for another URL")
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{
+			"https://fantasysports.yahooapis.com/fantasy/v2/league/314.l.431",
+			"/fantasy/v2/league/314.l.431",
+		},
+		{
+			"http://127.0.0.1:1234/fantasy/v2/team/314.l.431.t.1/roster;week=2",
+			"/fantasy/v2/team/314.l.431.t.1/roster;week=2",
+		},
+		{
+			"http://example.com/fantasy/v2/game?oauth_nonce=1&format=json&b=2",
+			"/fantasy/v2/game?b=2&format=json",
+		},
+		{
+			"http://example.com/fantasy/v2/league/314.l.431%2Fx",
+			"/fantasy/v2/league/314.l.431%2Fx",
+		},
+	}
+	for _, test := range tests {
+		assertStringEquals(t, test.expected, NormalizeURL(test.url))
+	}
+}
+
+//
+// Mocks
+//
+
+// getOnlyClient hides all methods of a HTTPClient other than Get
+type getOnlyClient struct {
+	client goff.HTTPClient
+}
+
+func (c getOnlyClient) Get(url string) (*http.Response, error) {
+	return c.client.Get(url)
+}
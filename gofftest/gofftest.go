@@ -0,0 +1,1086 @@
+// Package gofftest provides a fake Yahoo fantasy sports API for testing code
+// that uses goff, serving leagues stored in memory from a local HTTP server.
+//
+//    server := gofftest.NewServer(gofftest.League{
+//        League: goff.League{LeagueKey: "223.l.431", Name: "League"},
+//        Teams:  []goff.Team{{TeamKey: "223.l.431.t.1", TeamID: 1}},
+//    })
+//    defer server.Close()
+//
+//    client := server.Client()
+//    team, err := client.GetTeam("223.l.431.t.1")
+//
+// The server understands the URLs built by goff.URLBuilder for leagues,
+// teams, players, transactions, and the current user, as well as the write
+// operations used to edit rosters and make transactions. Only XML responses
+// are supported. Errors and latency can be injected to test how failures are
+// handled.
+package gofftest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/e0/goff"
+)
+
+// BasePath is the path of the fantasy sports API on the server.
+const BasePath = "/fantasy/v2"
+
+// namespace of the responses of the API
+const namespace = "http://fantasysports.yahooapis.com/fantasy/v2/base.rng"
+
+// League is the content served by a Server for a single league.
+type League struct {
+	// Metadata and settings of the league. Its Teams, Players, Standings, and
+	// Scoreboard are ignored in favour of the fields below.
+	League goff.League
+	// Teams of the league, including their rosters, stats, and standings.
+	// The Matchups of each team are ignored in favour of Matchups.
+	Teams []goff.Team
+	// Players that are available to be added to a team
+	Players []goff.Player
+	// Matchups of every week
+	Matchups []goff.Matchup
+	// Transactions made in the league, including those made using the
+	// server
+	Transactions []Transaction
+}
+
+// Transaction adds, drops, or trades players between teams.
+type Transaction struct {
+	TransactionKey string              `xml:"transaction_key"`
+	TransactionID  int                 `xml:"transaction_id"`
+	Type           string              `xml:"type"`
+	Status         string              `xml:"status"`
+	Timestamp      int64               `xml:"timestamp"`
+	Players        []TransactionPlayer `xml:"players>player"`
+}
+
+// TransactionPlayer is a player moved by a transaction.
+type TransactionPlayer struct {
+	PlayerKey       string          `xml:"player_key"`
+	Name            goff.Name       `xml:"name"`
+	TransactionData TransactionData `xml:"transaction_data"`
+}
+
+// TransactionData describes where a player is moved by a transaction.
+type TransactionData struct {
+	// "add" or "drop"
+	Type               string `xml:"type"`
+	SourceType         string `xml:"source_type,omitempty"`
+	SourceTeamKey      string `xml:"source_team_key,omitempty"`
+	DestinationType    string `xml:"destination_type,omitempty"`
+	DestinationTeamKey string `xml:"destination_team_key,omitempty"`
+}
+
+// Fault causes requests to the server to fail.
+type Fault struct {
+	// Fails requests whose escaped path contains this string, or every
+	// request when empty
+	Path string
+	// Status code of the failed response, e.g. http.StatusInternalServerError
+	// or goff.StatusYahooThrottled
+	StatusCode int
+	// Description of the error included in the response
+	Description string
+	// Amount of requests to fail, or zero to fail every matching request
+	// until the faults are cleared
+	Times int
+}
+
+// Server is a fake Yahoo fantasy sports API. It is safe for concurrent use.
+type Server struct {
+	// Base URL of the API served, e.g. "http://127.0.0.1:1234/fantasy/v2"
+	URL string
+
+	server *httptest.Server
+
+	mutex    sync.Mutex
+	leagues  []*League
+	faults   []*Fault
+	latency  time.Duration
+	requests []string
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	status      int
+	description string
+}
+
+// segment is a single segment of a request path, with its matrix parameters.
+type segment struct {
+	name   string
+	params map[string][]string
+}
+
+// fantasyContent is the root element of every response.
+type fantasyContent struct {
+	XMLName     xml.Name       `xml:"fantasy_content"`
+	Users       []goff.User    `xml:"users>user,omitempty"`
+	League      *leagueContent `xml:"league,omitempty"`
+	Team        *goff.Team     `xml:"team,omitempty"`
+	Player      *goff.Player   `xml:"player,omitempty"`
+	Transaction *Transaction   `xml:"transaction,omitempty"`
+}
+
+// leagueContent adds the transactions of a league to its response.
+type leagueContent struct {
+	goff.League
+	Transactions []Transaction `xml:"transactions>transaction,omitempty"`
+}
+
+// errorContent is the body of an error response.
+type errorContent struct {
+	XMLName     xml.Name `xml:"error"`
+	Description string   `xml:"description"`
+}
+
+// rosterRequest is the body of a request to edit a roster.
+type rosterRequest struct {
+	Roster struct {
+		CoverageType string `xml:"coverage_type"`
+		Week         int    `xml:"week"`
+		Players      []struct {
+			PlayerKey string `xml:"player_key"`
+			Position  string `xml:"position"`
+		} `xml:"players>player"`
+	} `xml:"roster"`
+}
+
+// transactionRequest is the body of a request to make a transaction.
+type transactionRequest struct {
+	Transaction struct {
+		Type    string              `xml:"type"`
+		Player  *TransactionPlayer  `xml:"player"`
+		Players []TransactionPlayer `xml:"players>player"`
+	} `xml:"transaction"`
+}
+
+// NewServer starts a Server serving the given leagues. The leagues are
+// copied, so writes made using the server do not modify them. The server
+// must be closed once it is no longer needed.
+func NewServer(leagues ...League) *Server {
+	s := &Server{}
+	for _, league := range leagues {
+		s.leagues = append(s.leagues, cloneLeague(league))
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL + BasePath
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client creates a goff.Client that makes requests to the server, configured
+// by the given options in addition to the server's base URL.
+func (s *Server) Client(opts ...goff.Option) *goff.Client {
+	return goff.New(
+		s.server.Client(),
+		append([]goff.Option{goff.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// HTTPClient returns a http.Client that can make requests to the server.
+func (s *Server) HTTPClient() *http.Client {
+	return s.server.Client()
+}
+
+// League returns a copy of the current content of the league with the given
+// key, including any changes made using the server.
+func (s *Server) League(leagueKey string) (League, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	league := s.league(leagueKey)
+	if league == nil {
+		return League{}, false
+	}
+	return *cloneLeague(*league), true
+}
+
+// Fail causes requests matching the fault to fail.
+func (s *Server) Fail(fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults stops every request from failing.
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = nil
+}
+
+// SetLatency delays every response by the given duration.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency = latency
+}
+
+// Requests returns the method and URL of each request made to the server,
+// e.g. "GET /fantasy/v2/league/223.l.431".
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+//
+// http.Handler
+//
+
+// ServeHTTP responds to a request to the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	latency := s.latency
+	fault := s.fault(r.URL.EscapedPath())
+	s.mutex.Unlock()
+
+	time.Sleep(latency)
+	if fault != nil {
+		status := fault.StatusCode
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		writeError(w, &apiError{status, fault.Description})
+		return
+	}
+
+	content, err := s.serve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if r.Method == "POST" {
+		status = http.StatusCreated
+	}
+	writeContent(w, status, content)
+}
+
+// fault returns the fault matching the path, if any, counting the request
+// towards the amount of times it fails.
+func (s *Server) fault(path string) *Fault {
+	for i, fault := range s.faults {
+		if !strings.Contains(path, fault.Path) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// serve returns the content for the request, making any changes it
+// requests.
+func (s *Server) serve(r *http.Request) (*fantasyContent, *apiError) {
+	if r.URL.Query().Get("format") == "json" {
+		return nil, badRequest("format=json is not supported")
+	}
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, BasePath+"/") {
+		return nil, &apiError{http.StatusNotFound, "not found: " + path}
+	}
+	segments, err := parseSegments(path[len(BasePath)+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	resource := segments[0]
+	if resource.name == "users" {
+		return s.users(r.Method, segments)
+	}
+	if len(segments) < 2 {
+		return nil, badRequest("missing key for resource " + resource.name)
+	}
+	key := segments[1]
+	out := key.params["out"]
+	rest := segments[2:]
+
+	switch {
+	case resource.name == "league" && r.Method == "GET":
+		league := s.league(key.name)
+		if league == nil {
+			return nil, notFound("league", key.name)
+		}
+		content, err := getLeague(league, out, rest)
+		return &fantasyContent{League: content}, err
+	case resource.name == "league" && r.Method == "POST" &&
+		len(rest) == 1 && rest[0].name == "transactions":
+		league := s.league(key.name)
+		if league == nil {
+			return nil, notFound("league", key.name)
+		}
+		transaction, err := addTransaction(league, r)
+		return &fantasyContent{Transaction: transaction}, err
+	case resource.name == "team" && r.Method == "GET":
+		league, team := s.team(key.name)
+		if team == nil {
+			return nil, notFound("team", key.name)
+		}
+		content, err := getTeam(league, team, out, rest)
+		return &fantasyContent{Team: content}, err
+	case resource.name == "team" && r.Method == "PUT" &&
+		len(rest) == 1 && rest[0].name == "roster":
+		_, team := s.team(key.name)
+		if team == nil {
+			return nil, notFound("team", key.name)
+		}
+		content, err := editRoster(team, r)
+		return &fantasyContent{Team: content}, err
+	case resource.name == "player" && r.Method == "GET":
+		player := s.player(key.name)
+		if player == nil {
+			return nil, notFound("player", key.name)
+		}
+		content := playerContent(
+			*player,
+			playerSubresources(key, rest),
+			goff.Ownership{})
+		return &fantasyContent{Player: &content}, nil
+	case resource.name == "transaction" && r.Method == "GET":
+		transaction := s.transaction(key.name)
+		if transaction == nil {
+			return nil, notFound("transaction", key.name)
+		}
+		return &fantasyContent{Transaction: transaction}, nil
+	}
+	return nil, &apiError{
+		http.StatusMethodNotAllowed,
+		fmt.Sprintf("%s is not supported for %s", r.Method, path),
+	}
+}
+
+// users returns the games and leagues of the current user, for URLs of the
+// form "users;use_login=1/games;game_keys=<keys>/leagues".
+func (s *Server) users(
+	method string,
+	segments []segment) (*fantasyContent, *apiError) {
+
+	if method != "GET" {
+		return nil, &apiError{http.StatusMethodNotAllowed, method + " users"}
+	}
+	if len(segments) < 2 || segments[1].name != "games" {
+		return nil, badRequest("only games of the current user are supported")
+	}
+
+	var games []goff.Game
+	for _, gameKey := range segments[1].params["game_keys"] {
+		game := goff.Game{}
+		for _, league := range s.leagues {
+			if inGame(league.League.LeagueKey, gameKey) {
+				game.Leagues = append(game.Leagues, leagueMetadata(league))
+			}
+		}
+		games = append(games, game)
+	}
+	return &fantasyContent{Users: []goff.User{{Games: games}}}, nil
+}
+
+// league returns the league with the given key.
+func (s *Server) league(leagueKey string) *League {
+	for _, league := range s.leagues {
+		if league.League.LeagueKey == leagueKey {
+			return league
+		}
+	}
+	return nil
+}
+
+// team returns the team with the given key and its league.
+func (s *Server) team(teamKey string) (*League, *goff.Team) {
+	for _, league := range s.leagues {
+		for i := range league.Teams {
+			if league.Teams[i].TeamKey == teamKey {
+				return league, &league.Teams[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// player returns the player with the given key from any league.
+func (s *Server) player(playerKey string) *goff.Player {
+	for _, league := range s.leagues {
+		players := leaguePlayers(league)
+		for i := range players {
+			if players[i].PlayerKey == playerKey {
+				return &players[i]
+			}
+		}
+	}
+	return nil
+}
+
+// transaction returns the transaction with the given key from any league.
+func (s *Server) transaction(transactionKey string) *Transaction {
+	for _, league := range s.leagues {
+		for i := range league.Transactions {
+			if league.Transactions[i].TransactionKey == transactionKey {
+				return &league.Transactions[i]
+			}
+		}
+	}
+	return nil
+}
+
+//
+// Resources
+//
+
+// getLeague returns the league with the sub-resources requested by the out
+// parameter and the rest of the path.
+func getLeague(
+	league *League,
+	out []string,
+	rest []segment) (*leagueContent, *apiError) {
+
+	content := &leagueContent{League: leagueMetadata(league)}
+	subresources, tail := subresources(out, rest)
+
+	for _, sub := range subresources {
+		switch sub.name {
+		case "metadata":
+		case "settings":
+			content.Settings = league.League.Settings
+		case "standings":
+			content.Standings = standings(league)
+		case "scoreboard":
+			content.Scoreboard = scoreboard(league, sub.params["week"])
+		case "teams":
+			teams, err := leagueTeams(league, sub, tail)
+			if err != nil {
+				return nil, err
+			}
+			content.Teams = teams
+		case "players":
+			players, err := filterPlayers(leaguePlayers(league), sub)
+			if err != nil {
+				return nil, err
+			}
+			for _, player := range players {
+				content.Players = append(
+					content.Players,
+					playerContent(
+						player,
+						playerSubresources(sub, tail),
+						playerOwnership(league, player.PlayerKey)))
+			}
+		case "transactions":
+			content.Transactions = filterTransactions(league, sub)
+		default:
+			return nil, badRequest("invalid league sub-resource " + sub.name)
+		}
+	}
+	return content, nil
+}
+
+// leagueTeams returns the teams of the league, filtered by the "team_keys"
+// parameter, with the sub-resource in the rest of the path.
+func leagueTeams(
+	league *League,
+	teams segment,
+	rest []segment) ([]goff.Team, *apiError) {
+
+	keys := teams.params["team_keys"]
+	var result []goff.Team
+	for i := range league.Teams {
+		team := &league.Teams[i]
+		if len(keys) > 0 && !contains(keys, team.TeamKey) {
+			continue
+		}
+		content, err := getTeam(league, team, nil, rest)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *content)
+	}
+	return result, nil
+}
+
+// getTeam returns the team with the sub-resources requested by the out
+// parameter and the rest of the path.
+func getTeam(
+	league *League,
+	team *goff.Team,
+	out []string,
+	rest []segment) (*goff.Team, *apiError) {
+
+	content := teamMetadata(*team)
+	subresources, tail := subresources(out, rest)
+
+	for _, sub := range subresources {
+		switch sub.name {
+		case "metadata":
+		case "stats":
+			content.TeamPoints = team.TeamPoints
+			content.TeamProjectedPoints = team.TeamProjectedPoints
+			content.TeamStats = team.TeamStats
+		case "standings":
+			content.TeamStandings = team.TeamStandings
+		case "roster":
+			content.Roster = roster(team.Roster, sub.params["week"])
+		case "players":
+			for _, player := range team.Roster.Players {
+				content.Players = append(
+					content.Players,
+					playerContent(
+						player,
+						playerSubresources(sub, tail),
+						teamOwnership(team)))
+			}
+		case "matchups":
+			content.Matchups = teamMatchups(league, team, sub.params["weeks"])
+		default:
+			return nil, badRequest("invalid team sub-resource " + sub.name)
+		}
+	}
+	return &content, nil
+}
+
+// leagueMetadata returns the metadata of the league without any of its
+// sub-resources.
+func leagueMetadata(league *League) goff.League {
+	content := league.League
+	content.Teams = nil
+	content.Players = nil
+	content.Standings = nil
+	content.Scoreboard = goff.Scoreboard{}
+	content.Settings = goff.Settings{}
+	return content
+}
+
+// teamMetadata returns the metadata of the team without any of its
+// sub-resources.
+func teamMetadata(team goff.Team) goff.Team {
+	return goff.Team{
+		TeamKey:               team.TeamKey,
+		TeamID:                team.TeamID,
+		Name:                  team.Name,
+		URL:                   team.URL,
+		TeamLogos:             team.TeamLogos,
+		IsOwnedByCurrentLogin: team.IsOwnedByCurrentLogin,
+		WavierPriority:        team.WavierPriority,
+		NumberOfMoves:         team.NumberOfMoves,
+		NumberOfTrades:        team.NumberOfTrades,
+		Managers:              team.Managers,
+	}
+}
+
+// playerContent returns the player, including their stats, percent owned,
+// percent started, and ownership only if requested.
+func playerContent(
+	player goff.Player,
+	subresources []string,
+	ownership goff.Ownership) goff.Player {
+
+	if !contains(subresources, "stats") {
+		player.PlayerPoints = goff.Points{}
+		player.PlayerStats = goff.SeasonStats{}
+	}
+	if !contains(subresources, "percent_owned") {
+		player.PercentOwned = goff.Percent{}
+	}
+	if !contains(subresources, "percent_started") {
+		player.PercentStarted = goff.Percent{}
+	}
+	player.Ownership = goff.Ownership{}
+	if contains(subresources, "ownership") {
+		player.Ownership = ownership
+	}
+	return player
+}
+
+// playerSubresources returns the names of the sub-resources requested for a
+// player or collection of players, by its out parameter or the rest of the
+// path.
+func playerSubresources(players segment, rest []segment) []string {
+	names := append([]string{}, players.params["out"]...)
+	for _, s := range rest {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// playerOwnership returns the ownership of the player in the league, which
+// is either the team with the player on its roster or free agency.
+func playerOwnership(league *League, playerKey string) goff.Ownership {
+	for i := range league.Teams {
+		if rosterIndex(&league.Teams[i], playerKey) >= 0 {
+			return teamOwnership(&league.Teams[i])
+		}
+	}
+	return goff.Ownership{OwnershipType: "freeagents"}
+}
+
+// teamOwnership returns the ownership of players on the team's roster.
+func teamOwnership(team *goff.Team) goff.Ownership {
+	return goff.Ownership{
+		OwnershipType: "team",
+		OwnerTeamKey:  team.TeamKey,
+		OwnerTeamName: team.Name,
+	}
+}
+
+// standings returns the teams of the league ordered by rank.
+func standings(league *League) []goff.Team {
+	var teams []goff.Team
+	for _, team := range league.Teams {
+		content := teamMetadata(team)
+		content.TeamPoints = team.TeamPoints
+		content.TeamStandings = team.TeamStandings
+		teams = append(teams, content)
+	}
+	sort.SliceStable(teams, func(i, j int) bool {
+		return teams[i].TeamStandings.Rank < teams[j].TeamStandings.Rank
+	})
+	return teams
+}
+
+// scoreboard returns the matchups of the league for the given weeks, or the
+// current week if none are given.
+func scoreboard(league *League, weeks []string) goff.Scoreboard {
+	if len(weeks) == 0 {
+		weeks = []string{strconv.Itoa(league.League.CurrentWeek)}
+	}
+	content := goff.Scoreboard{Weeks: strings.Join(weeks, ",")}
+	for _, matchup := range league.Matchups {
+		if contains(weeks, strconv.Itoa(matchup.Week)) {
+			content.Matchups = append(content.Matchups, matchupContent(matchup))
+		}
+	}
+	return content
+}
+
+// teamMatchups returns the matchups of the team for the given weeks, or
+// every week if none are given.
+func teamMatchups(
+	league *League,
+	team *goff.Team,
+	weeks []string) []goff.Matchup {
+
+	var matchups []goff.Matchup
+	for _, matchup := range league.Matchups {
+		if len(weeks) > 0 && !contains(weeks, strconv.Itoa(matchup.Week)) {
+			continue
+		}
+		for _, opponent := range matchup.Teams {
+			if opponent.TeamKey == team.TeamKey {
+				matchups = append(matchups, matchupContent(matchup))
+				break
+			}
+		}
+	}
+	return matchups
+}
+
+// matchupContent returns the matchup with the points of each team.
+func matchupContent(matchup goff.Matchup) goff.Matchup {
+	teams := matchup.Teams
+	matchup.Teams = nil
+	for _, team := range teams {
+		content := teamMetadata(team)
+		content.TeamPoints = team.TeamPoints
+		content.TeamProjectedPoints = team.TeamProjectedPoints
+		matchup.Teams = append(matchup.Teams, content)
+	}
+	return matchup
+}
+
+// roster returns the roster for the given week. Rosters are the same for
+// every week.
+func roster(roster goff.Roster, week []string) goff.Roster {
+	content := goff.Roster{CoverageType: "week", Week: roster.Week}
+	if len(week) > 0 {
+		content.Week, _ = strconv.Atoi(week[0])
+	}
+	for _, player := range roster.Players {
+		player = playerContent(player, nil, goff.Ownership{})
+		player.SelectedPosition.CoverageType = "week"
+		player.SelectedPosition.Week = content.Week
+		content.Players = append(content.Players, player)
+	}
+	return content
+}
+
+// leaguePlayers returns the players on the roster of each team in the league
+// followed by the available players.
+func leaguePlayers(league *League) []goff.Player {
+	var players []goff.Player
+	for _, team := range league.Teams {
+		players = append(players, team.Roster.Players...)
+	}
+	return append(players, league.Players...)
+}
+
+// filterPlayers returns the players matching the "player_keys" and "status"
+// parameters, paginated by the "start" and "count" parameters.
+func filterPlayers(
+	players []goff.Player,
+	collection segment) ([]goff.Player, *apiError) {
+
+	keys := collection.params["player_keys"]
+	var result []goff.Player
+	for _, player := range players {
+		if len(keys) == 0 || contains(keys, player.PlayerKey) {
+			result = append(result, player)
+		}
+	}
+
+	start, err := intParam(collection, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := intParam(collection, "count", len(result))
+	if err != nil {
+		return nil, err
+	}
+	if start > len(result) {
+		start = len(result)
+	}
+	if start+count < len(result) {
+		result = result[:start+count]
+	}
+	return result[start:], nil
+}
+
+// filterTransactions returns the transactions of the league matching the
+// "type" parameter.
+func filterTransactions(league *League, collection segment) []Transaction {
+	types := collection.params["type"]
+	var transactions []Transaction
+	for _, transaction := range league.Transactions {
+		if len(types) == 0 || contains(types, transaction.Type) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions
+}
+
+//
+// Writes
+//
+
+// editRoster changes the selected positions of the players on the team's
+// roster to those in the body of the request.
+func editRoster(team *goff.Team, r *http.Request) (*goff.Team, *apiError) {
+	var request rosterRequest
+	if err := readRequest(r, &request); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]string)
+	for _, player := range request.Roster.Players {
+		if rosterIndex(team, player.PlayerKey) < 0 {
+			return nil, badRequest(fmt.Sprintf(
+				"player %s is not on the roster of team %s",
+				player.PlayerKey,
+				team.TeamKey))
+		}
+		positions[player.PlayerKey] = player.Position
+	}
+	for i := range team.Roster.Players {
+		player := &team.Roster.Players[i]
+		if position, ok := positions[player.PlayerKey]; ok {
+			player.SelectedPosition.Position = position
+		}
+	}
+
+	content := teamMetadata(*team)
+	content.Roster = roster(team.Roster, []string{
+		strconv.Itoa(request.Roster.Week),
+	})
+	return &content, nil
+}
+
+// addTransaction adds and drops the players in the body of the request,
+// recording the transaction in the league.
+func addTransaction(league *League, r *http.Request) (*Transaction, *apiError) {
+	var request transactionRequest
+	if err := readRequest(r, &request); err != nil {
+		return nil, err
+	}
+	players := request.Transaction.Players
+	if request.Transaction.Player != nil {
+		players = append(players, *request.Transaction.Player)
+	}
+	if len(players) == 0 {
+		return nil, badRequest("transaction does not include any players")
+	}
+
+	// Check every move before making any of them
+	for _, player := range players {
+		if err := checkMove(league, player); err != nil {
+			return nil, err
+		}
+	}
+
+	transaction := Transaction{
+		TransactionID: len(league.Transactions) + 1,
+		Type:          request.Transaction.Type,
+		Status:        "successful",
+		Timestamp:     time.Now().Unix(),
+	}
+	transaction.TransactionKey = fmt.Sprintf(
+		"%s.tr.%d",
+		league.League.LeagueKey,
+		transaction.TransactionID)
+	for _, player := range players {
+		player.Name = movePlayer(league, player)
+		transaction.Players = append(transaction.Players, player)
+	}
+	league.Transactions = append(league.Transactions, transaction)
+	return &transaction, nil
+}
+
+// checkMove returns an error if the player can't be moved as described by
+// the transaction data.
+func checkMove(league *League, player TransactionPlayer) *apiError {
+	data := player.TransactionData
+	switch data.Type {
+	case "add":
+		if availableIndex(league, player.PlayerKey) < 0 {
+			return badRequest("player " + player.PlayerKey + " is not available")
+		}
+		if teamByKey(league, data.DestinationTeamKey) == nil {
+			return notFound("team", data.DestinationTeamKey)
+		}
+	case "drop":
+		team := teamByKey(league, data.SourceTeamKey)
+		if team == nil {
+			return notFound("team", data.SourceTeamKey)
+		}
+		if rosterIndex(team, player.PlayerKey) < 0 {
+			return badRequest(fmt.Sprintf(
+				"player %s is not on the roster of team %s",
+				player.PlayerKey,
+				team.TeamKey))
+		}
+	default:
+		return badRequest("invalid transaction type " + data.Type)
+	}
+	return nil
+}
+
+// movePlayer adds or drops the player as described by the transaction data,
+// returning the player's name.
+func movePlayer(league *League, player TransactionPlayer) goff.Name {
+	data := player.TransactionData
+	if data.Type == "add" {
+		index := availableIndex(league, player.PlayerKey)
+		moved := league.Players[index]
+		league.Players = append(league.Players[:index], league.Players[index+1:]...)
+		moved.SelectedPosition = goff.SelectedPosition{Position: "BN"}
+		team := teamByKey(league, data.DestinationTeamKey)
+		team.Roster.Players = append(team.Roster.Players, moved)
+		return moved.Name
+	}
+
+	team := teamByKey(league, data.SourceTeamKey)
+	index := rosterIndex(team, player.PlayerKey)
+	moved := team.Roster.Players[index]
+	team.Roster.Players = append(
+		team.Roster.Players[:index],
+		team.Roster.Players[index+1:]...)
+	moved.SelectedPosition = goff.SelectedPosition{}
+	league.Players = append(league.Players, moved)
+	return moved.Name
+}
+
+//
+// Helpers
+//
+
+// parseSegments parses the escaped segments of a path, e.g.
+// "league/223.l.431/players;player_keys=223.p.1,223.p.2".
+func parseSegments(path string) ([]segment, *apiError) {
+	var segments []segment
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		params := strings.Split(part, ";")
+		name, err := url.PathUnescape(params[0])
+		if err != nil {
+			return nil, badRequest("invalid path: " + path)
+		}
+		s := segment{name: name, params: make(map[string][]string)}
+		for _, param := range params[1:] {
+			keyValue := strings.SplitN(param, "=", 2)
+			if len(keyValue) != 2 {
+				return nil, badRequest("invalid parameter: " + param)
+			}
+			for _, value := range strings.Split(keyValue[1], ",") {
+				value, err := url.PathUnescape(value)
+				if err != nil {
+					return nil, badRequest("invalid parameter: " + param)
+				}
+				s.params[keyValue[0]] = append(s.params[keyValue[0]], value)
+			}
+		}
+		segments = append(segments, s)
+	}
+	if len(segments) == 0 {
+		return nil, badRequest("missing resource")
+	}
+	return segments, nil
+}
+
+// subresources returns a segment for each sub-resource requested by the out
+// parameter of a resource, followed by the first segment of the rest of the
+// path. The segments following that sub-resource are also returned.
+func subresources(out []string, rest []segment) ([]segment, []segment) {
+	var segments []segment
+	for _, name := range out {
+		segments = append(segments, segment{name: name})
+	}
+	if len(rest) == 0 {
+		return segments, nil
+	}
+	return append(segments, rest[0]), rest[1:]
+}
+
+// intParam returns the integer value of a parameter of the segment, or the
+// default value when it is not set.
+func intParam(s segment, key string, value int) (int, *apiError) {
+	values := s.params[key]
+	if len(values) == 0 {
+		return value, nil
+	}
+	value, err := strconv.Atoi(values[0])
+	if err != nil || value < 0 {
+		return 0, badRequest(fmt.Sprintf("invalid %s: %s", key, values[0]))
+	}
+	return value, nil
+}
+
+// inGame reports whether the league belongs to the game with the given key.
+// Game codes such as "nfl" match every league.
+func inGame(leagueKey string, gameKey string) bool {
+	if _, err := strconv.Atoi(gameKey); err != nil {
+		return true
+	}
+	return strings.HasPrefix(leagueKey, gameKey+".l.")
+}
+
+// teamByKey returns the team of the league with the given key.
+func teamByKey(league *League, teamKey string) *goff.Team {
+	for i := range league.Teams {
+		if league.Teams[i].TeamKey == teamKey {
+			return &league.Teams[i]
+		}
+	}
+	return nil
+}
+
+// rosterIndex returns the index of the player on the team's roster, or -1.
+func rosterIndex(team *goff.Team, playerKey string) int {
+	for i, player := range team.Roster.Players {
+		if player.PlayerKey == playerKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// availableIndex returns the index of the player in the league's available
+// players, or -1.
+func availableIndex(league *League, playerKey string) int {
+	for i, player := range league.Players {
+		if player.PlayerKey == playerKey {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneLeague copies the league, including the teams, rosters, and players
+// that are changed by writes.
+func cloneLeague(league League) *League {
+	league.Teams = append([]goff.Team{}, league.Teams...)
+	for i := range league.Teams {
+		roster := &league.Teams[i].Roster
+		roster.Players = append([]goff.Player{}, roster.Players...)
+	}
+	league.Players = append([]goff.Player{}, league.Players...)
+	league.Transactions = append([]Transaction{}, league.Transactions...)
+	return &league
+}
+
+// readRequest unmarshals the XML body of the request.
+func readRequest(r *http.Request, v interface{}) *apiError {
+	bits, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return badRequest("error reading request: " + err.Error())
+	}
+	if err := xml.Unmarshal(bits, v); err != nil {
+		return badRequest("invalid request: " + err.Error())
+	}
+	return nil
+}
+
+// writeContent writes the content as an XML response.
+func writeContent(w http.ResponseWriter, status int, content *fantasyContent) {
+	content.XMLName = xml.Name{Space: namespace, Local: "fantasy_content"}
+	writeXML(w, status, content)
+}
+
+// writeError writes the error as an XML response.
+func writeError(w http.ResponseWriter, err *apiError) {
+	writeXML(w, err.status, &errorContent{Description: err.description})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	bits, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=UTF-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(bits)
+}
+
+func badRequest(description string) *apiError {
+	return &apiError{http.StatusBadRequest, description}
+}
+
+func notFound(resource string, key string) *apiError {
+	return &apiError{
+		http.StatusBadRequest,
+		fmt.Sprintf("%s key %s does not exist.", resource, key),
+	}
+}
@@ -0,0 +1,567 @@
+package gofftest
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e0/goff"
+)
+
+//
+// Test Server reads
+//
+
+func TestGetLeagueMetadata(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	league, err := server.Client().GetLeagueMetadata("314.l.431")
+	if err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+	assertStringEquals(t, "Test League", league.Name)
+	assertIntEquals(t, 3, league.CurrentWeek)
+	if len(league.Teams) != 0 || len(league.Players) != 0 {
+		t.Fatalf("unexpected sub-resources returned: %+v", league)
+	}
+}
+
+func TestGetLeagueStandings(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	league, err := server.Client().GetLeagueStandings("314.l.431")
+	if err != nil {
+		t.Fatalf("error retrieving standings: %s", err)
+	}
+	if len(league.Standings) != 2 {
+		t.Fatalf("unexpected standings: %+v", league.Standings)
+	}
+	assertStringEquals(t, "Second", league.Standings[0].Name)
+	assertIntEquals(t, 1, league.Standings[0].TeamStandings.Rank)
+	assertStringEquals(t, "First", league.Standings[1].Name)
+	assertStringEquals(t, "head", league.Settings.ScoringType)
+}
+
+func TestGetUserLeagues(t *testing.T) {
+	other := testLeague()
+	other.League.LeagueKey = "331.l.1"
+	server := NewServer(testLeague(), other)
+	defer server.Close()
+
+	leagues, err := server.Client().GetUserLeagues("2013")
+	if err != nil {
+		t.Fatalf("error retrieving leagues: %s", err)
+	}
+	if len(leagues) != 1 || leagues[0].LeagueKey != "314.l.431" {
+		t.Fatalf("unexpected leagues: %+v", leagues)
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	team, err := server.Client().GetTeam("314.l.431.t.1")
+	if err != nil {
+		t.Fatalf("error retrieving team: %s", err)
+	}
+	assertStringEquals(t, "First", team.Name)
+	if team.TeamPoints.Total != 101.5 {
+		t.Fatalf("unexpected team points: %+v", team.TeamPoints)
+	}
+	assertIntEquals(t, 2, team.TeamStandings.Rank)
+	assertIntEquals(t, 2, len(team.Roster.Players))
+	assertIntEquals(t, 2, len(team.Players))
+}
+
+func TestGetTeamRoster(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	players, err := server.Client().GetTeamRoster("314.l.431.t.1", 2)
+	if err != nil {
+		t.Fatalf("error retrieving roster: %s", err)
+	}
+	if len(players) != 2 {
+		t.Fatalf("unexpected roster: %+v", players)
+	}
+	assertStringEquals(t, "QB", players[0].SelectedPosition.Position)
+	assertIntEquals(t, 2, players[0].SelectedPosition.Week)
+}
+
+func TestGetPlayersStats(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	players, err := server.Client().GetPlayersStats(
+		"314.l.431",
+		3,
+		[]goff.Player{{PlayerKey: "314.p.2"}, {PlayerKey: "314.p.9"}})
+	if err != nil {
+		t.Fatalf("error retrieving players: %s", err)
+	}
+	if len(players) != 2 {
+		t.Fatalf("unexpected players: %+v", players)
+	}
+	assertStringEquals(t, "314.p.2", players[0].PlayerKey)
+	if players[0].PlayerPoints.Total != 12.25 {
+		t.Fatalf("unexpected player points: %+v", players[0].PlayerPoints)
+	}
+	assertStringEquals(t, "Free Agent", players[1].Name.Full)
+}
+
+func TestGetPlayerDetails(t *testing.T) {
+	league := testLeague()
+	league.Teams[0].Roster.Players[0].PercentOwned = goff.Percent{
+		CoverageType: "week",
+		Week:         3,
+		Value:        97.5,
+		Delta:        -1,
+	}
+	server := NewServer(league)
+	defer server.Close()
+
+	players, err := server.Client().GetPlayerDetails(
+		"314.l.431",
+		[]string{"314.p.1", "314.p.9"})
+	if err != nil {
+		t.Fatalf("error retrieving players: %s", err)
+	}
+	if len(players) != 2 {
+		t.Fatalf("unexpected players: %+v", players)
+	}
+	if players[0].PercentOwned.Value != 97.5 || players[0].PercentOwned.Delta != -1 {
+		t.Fatalf("unexpected percent owned: %+v", players[0].PercentOwned)
+	}
+	assertStringEquals(t, "team", players[0].Ownership.OwnershipType)
+	assertStringEquals(t, "314.l.431.t.1", players[0].Ownership.OwnerTeamKey)
+	assertStringEquals(t, "First", players[0].Ownership.OwnerTeamName)
+	assertStringEquals(t, "freeagents", players[1].Ownership.OwnershipType)
+
+	// Only included when requested
+	roster, err := server.Client().GetTeamRoster("314.l.431.t.1", 3)
+	if err != nil {
+		t.Fatalf("error retrieving roster: %s", err)
+	}
+	if roster[0].PercentOwned.Value != 0 || roster[0].Ownership.OwnershipType != "" {
+		t.Fatalf("unexpected player details: %+v", roster[0])
+	}
+}
+
+func TestGetAllTeamStats(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	teams, err := server.Client().GetAllTeamStats("314.l.431", 3)
+	if err != nil {
+		t.Fatalf("error retrieving teams: %s", err)
+	}
+	if len(teams) != 2 || teams[1].TeamPoints.Total != 99 {
+		t.Fatalf("unexpected teams: %+v", teams)
+	}
+	if len(teams[0].Roster.Players) != 0 {
+		t.Fatalf("roster returned with team stats: %+v", teams[0])
+	}
+}
+
+func TestGetMatchups(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	client := server.Client()
+
+	matchups, err := client.GetMatchupsForWeekRange("314.l.431", 1, 2)
+	if err != nil {
+		t.Fatalf("error retrieving scoreboard: %s", err)
+	}
+	if len(matchups[1]) != 1 || len(matchups[2]) != 1 || len(matchups) != 2 {
+		t.Fatalf("unexpected matchups: %+v", matchups)
+	}
+
+	teamMatchups, err := client.GetTeamMatchupsForWeeks("314.l.431.t.2", []int{2})
+	if err != nil {
+		t.Fatalf("error retrieving team matchups: %s", err)
+	}
+	if len(teamMatchups) != 1 || teamMatchups[0].Week != 2 {
+		t.Fatalf("unexpected team matchups: %+v", teamMatchups)
+	}
+	if teamMatchups[0].Teams[0].TeamPoints.Total != 80 {
+		t.Fatalf("unexpected matchup teams: %+v", teamMatchups[0].Teams)
+	}
+}
+
+func TestStreamPlayers(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	var keys []string
+	err := server.Client().StreamPlayers(
+		server.Client().URL().League("314.l.431").AllPlayers().String(),
+		func(player goff.Player) error {
+			keys = append(keys, player.PlayerKey)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	assertStringEquals(t, "314.p.1,314.p.2,314.p.3,314.p.9", strings.Join(keys, ","))
+}
+
+func TestPlayersPagination(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	client := server.Client()
+
+	content, err := client.GetFantasyContent(client.URL().
+		League("314.l.431").
+		AllPlayers().Param("start", "1").Param("count", "2").
+		String())
+	if err != nil {
+		t.Fatalf("error retrieving players: %s", err)
+	}
+	players := content.League.Players
+	if len(players) != 2 || players[0].PlayerKey != "314.p.2" {
+		t.Fatalf("unexpected players: %+v", players)
+	}
+}
+
+func TestGetUnknownResources(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	client := server.Client(goff.WithRetryPolicy(&goff.RetryPolicy{}))
+
+	urls := []string{
+		client.URL().League("314.l.999").String(),
+		client.URL().Team("314.l.431.t.9").String(),
+		client.URL().Player("314.p.999").String(),
+		client.URL().League("314.l.431").Sub("unknown").String(),
+		client.URL().Sub("games").String(),
+	}
+	for _, url := range urls {
+		if _, err := client.GetFantasyContent(url); err == nil {
+			t.Fatalf("no error returned for %s", url)
+		}
+	}
+}
+
+func TestJSONUnsupported(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	client := server.Client(
+		goff.WithFormat(goff.FormatJSON),
+		goff.WithRetryPolicy(&goff.RetryPolicy{}))
+
+	if _, err := client.GetLeagueMetadata("314.l.431"); err == nil {
+		t.Fatalf("no error returned for a JSON request")
+	}
+}
+
+//
+// Test Server writes
+//
+
+func TestEditRoster(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	response := sendRequest(t, server, "PUT", "/team/314.l.431.t.1/roster", `
+<fantasy_content>
+  <roster>
+    <coverage_type>week</coverage_type>
+    <week>3</week>
+    <players>
+      <player><player_key>314.p.1</player_key><position>BN</position></player>
+    </players>
+  </roster>
+</fantasy_content>`)
+	assertIntEquals(t, http.StatusOK, response.StatusCode)
+
+	players, err := server.Client().GetTeamRoster("314.l.431.t.1", 3)
+	if err != nil {
+		t.Fatalf("error retrieving roster: %s", err)
+	}
+	assertStringEquals(t, "BN", players[0].SelectedPosition.Position)
+	assertStringEquals(t, "WR", players[1].SelectedPosition.Position)
+}
+
+func TestEditRosterUnknownPlayer(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	response := sendRequest(t, server, "PUT", "/team/314.l.431.t.1/roster", `
+<fantasy_content><roster><players>
+  <player><player_key>314.p.3</player_key><position>BN</position></player>
+</players></roster></fantasy_content>`)
+	assertIntEquals(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestAddDropTransaction(t *testing.T) {
+	league := testLeague()
+	server := NewServer(league)
+	defer server.Close()
+
+	response := sendRequest(t, server, "POST", "/league/314.l.431/transactions", `
+<fantasy_content>
+  <transaction>
+    <type>add/drop</type>
+    <players>
+      <player>
+        <player_key>314.p.9</player_key>
+        <transaction_data>
+          <type>add</type>
+          <destination_team_key>314.l.431.t.1</destination_team_key>
+        </transaction_data>
+      </player>
+      <player>
+        <player_key>314.p.2</player_key>
+        <transaction_data>
+          <type>drop</type>
+          <source_team_key>314.l.431.t.1</source_team_key>
+        </transaction_data>
+      </player>
+    </players>
+  </transaction>
+</fantasy_content>`)
+	assertIntEquals(t, http.StatusCreated, response.StatusCode)
+
+	var content struct {
+		Transaction Transaction `xml:"transaction"`
+	}
+	readResponse(t, response, &content)
+	assertStringEquals(t, "314.l.431.tr.1", content.Transaction.TransactionKey)
+	assertStringEquals(t, "Free Agent", content.Transaction.Players[0].Name.Full)
+
+	players, err := server.Client().GetTeamRoster("314.l.431.t.1", 3)
+	if err != nil {
+		t.Fatalf("error retrieving roster: %s", err)
+	}
+	if len(players) != 2 || players[1].PlayerKey != "314.p.9" {
+		t.Fatalf("unexpected roster after transaction: %+v", players)
+	}
+
+	updated, _ := server.League("314.l.431")
+	if len(updated.Players) != 1 || updated.Players[0].PlayerKey != "314.p.2" {
+		t.Fatalf("unexpected available players: %+v", updated.Players)
+	}
+	assertIntEquals(t, 1, len(updated.Transactions))
+	if len(league.Teams[0].Roster.Players) != 2 ||
+		league.Teams[0].Roster.Players[1].PlayerKey != "314.p.2" {
+		t.Fatalf("league passed to server modified: %+v", league.Teams[0])
+	}
+
+	response = sendRequest(
+		t,
+		server,
+		"GET",
+		"/league/314.l.431/transactions;type=add/drop",
+		"")
+	readResponse(t, response, &struct{}{})
+	response = sendRequest(t, server, "GET", "/transaction/314.l.431.tr.1", "")
+	assertIntEquals(t, http.StatusOK, response.StatusCode)
+}
+
+func TestTransactionUnavailablePlayer(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+
+	response := sendRequest(t, server, "POST", "/league/314.l.431/transactions", `
+<fantasy_content>
+  <transaction>
+    <type>add</type>
+    <player>
+      <player_key>314.p.3</player_key>
+      <transaction_data>
+        <type>add</type>
+        <destination_team_key>314.l.431.t.1</destination_team_key>
+      </transaction_data>
+    </player>
+  </transaction>
+</fantasy_content>`)
+	assertIntEquals(t, http.StatusBadRequest, response.StatusCode)
+
+	league, _ := server.League("314.l.431")
+	assertIntEquals(t, 0, len(league.Transactions))
+}
+
+//
+// Test faults
+//
+
+func TestFaultRetried(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	server.Fail(Fault{
+		Path:       "/league/",
+		StatusCode: http.StatusInternalServerError,
+		Times:      1,
+	})
+	client := server.Client(goff.WithRetryPolicy(&goff.RetryPolicy{
+		MaxAttempts: 2,
+	}))
+
+	if _, err := client.GetLeagueMetadata("314.l.431"); err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+	assertIntEquals(t, 2, len(server.Requests()))
+	if _, err := client.GetLeagueMetadata("314.l.431"); err != nil {
+		t.Fatalf("error retrieving league after fault: %s", err)
+	}
+}
+
+func TestFaultUntilCleared(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	server.Fail(Fault{
+		StatusCode:  goff.StatusYahooThrottled,
+		Description: "Request denied",
+	})
+	client := server.Client(goff.WithRetryPolicy(&goff.RetryPolicy{}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetLeagueMetadata("314.l.431"); err == nil {
+			t.Fatalf("no error returned while throttled")
+		}
+	}
+	if _, err := client.GetTeam("314.l.431.t.1"); err == nil {
+		t.Fatalf("no error returned while throttled")
+	}
+
+	server.ClearFaults()
+	if _, err := client.GetLeagueMetadata("314.l.431"); err != nil {
+		t.Fatalf("error retrieving league after faults cleared: %s", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	server := NewServer(testLeague())
+	defer server.Close()
+	server.SetLatency(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := server.Client().GetLeagueMetadata("314.l.431"); err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("response not delayed: %s", elapsed)
+	}
+}
+
+//
+// Helpers
+//
+
+func sendRequest(
+	t *testing.T,
+	server *Server,
+	method string,
+	path string,
+	body string) *http.Response {
+
+	request, err := http.NewRequest(
+		method,
+		server.URL+path,
+		strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("error creating request: %s", err)
+	}
+	response, err := server.HTTPClient().Do(request)
+	if err != nil {
+		t.Fatalf("error sending request: %s", err)
+	}
+	return response
+}
+
+func readResponse(t *testing.T, response *http.Response, v interface{}) {
+	defer response.Body.Close()
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error reading response: %s", err)
+	}
+	if response.StatusCode != http.StatusOK &&
+		response.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected response %d: %s", response.StatusCode, bits)
+	}
+	if err := xml.Unmarshal(bits, v); err != nil {
+		t.Fatalf("error unmarshalling response: %s\n%s", err, bits)
+	}
+}
+
+func assertStringEquals(t *testing.T, expected string, actual string) {
+	if expected != actual {
+		t.Fatalf("Unexpected content\n"+
+			"\tactual: %s\n"+
+			"\texpected: %s",
+			actual,
+			expected)
+	}
+}
+
+func assertIntEquals(t *testing.T, expected int, actual int) {
+	if expected != actual {
+		t.Fatalf("Unexpected content\n"+
+			"\tactual: %d\n"+
+			"\texpected: %d",
+			actual,
+			expected)
+	}
+}
+
+func testLeague() League {
+	player := func(key string, name string, position string) goff.Player {
+		return goff.Player{
+			PlayerKey:        key,
+			Name:             goff.Name{Full: name},
+			SelectedPosition: goff.SelectedPosition{Position: position},
+		}
+	}
+	first := goff.Team{
+		TeamKey:       "314.l.431.t.1",
+		TeamID:        1,
+		Name:          "First",
+		TeamPoints:    goff.Points{Total: 101.5},
+		TeamStandings: goff.TeamStandings{Rank: 2},
+		Roster: goff.Roster{
+			Players: []goff.Player{
+				player("314.p.1", "Quarterback", "QB"),
+				player("314.p.2", "Receiver", "WR"),
+			},
+		},
+	}
+	first.Roster.Players[1].PlayerPoints = goff.Points{Total: 12.25}
+	second := goff.Team{
+		TeamKey:       "314.l.431.t.2",
+		TeamID:        2,
+		Name:          "Second",
+		TeamPoints:    goff.Points{Total: 99},
+		TeamStandings: goff.TeamStandings{Rank: 1},
+		Roster: goff.Roster{
+			Players: []goff.Player{player("314.p.3", "Runner", "RB")},
+		},
+	}
+
+	matchup := func(week int, firstPoints, secondPoints float64) goff.Matchup {
+		return goff.Matchup{
+			Week: week,
+			Teams: []goff.Team{
+				{TeamKey: first.TeamKey, TeamPoints: goff.Points{Total: firstPoints}},
+				{TeamKey: second.TeamKey, TeamPoints: goff.Points{Total: secondPoints}},
+			},
+		}
+	}
+	return League{
+		League: goff.League{
+			LeagueKey:   "314.l.431",
+			LeagueID:    431,
+			Name:        "Test League",
+			CurrentWeek: 3,
+			Settings:    goff.Settings{ScoringType: "head"},
+		},
+		Teams:    []goff.Team{first, second},
+		Players:  []goff.Player{player("314.p.9", "Free Agent", "")},
+		Matchups: []goff.Matchup{matchup(1, 100, 90), matchup(2, 80, 85)},
+	}
+}
@@ -0,0 +1,196 @@
+package goff
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the format of the responses requested from the Yahoo fantasy
+// sports API.
+type Format int
+
+const (
+	// FormatXML requests XML responses. This is the default format.
+	FormatXML Format = iota
+	// FormatJSON requests JSON responses, using "format=json"
+	FormatJSON
+)
+
+// jsonDecoder decodes the JSON responses of the API
+var jsonDecoder = contentDecoder{
+	span:       "goff.json.decode",
+	newDecoder: newJSONDecoder,
+}
+
+// jsonConverter translates a JSON response of the API into the XML tokens of
+// the equivalent XML response.
+//
+// The JSON responses are derived from the XML responses: elements become
+// object keys, but lists of elements are split into objects keyed by their
+// index, and the children of an element are often split across an array of
+// objects. For example, the following JSON
+//
+//    {"team": [[{"team_key": "223.l.431.t.1"}, {"name": "Team"}],
+//        {"managers": [{"manager": {"nickname": "Nickname"}}]}]}
+//
+// is equivalent to the following XML:
+//
+//    <team>
+//      <team_key>223.l.431.t.1</team_key>
+//      <name>Team</name>
+//      <managers><manager><nickname>Nickname</nickname></manager></managers>
+//    </team>
+type jsonConverter struct {
+	decoder *json.Decoder
+	tokens  []xml.Token
+}
+
+// xmlTokens implements xml.TokenReader for a list of tokens.
+type xmlTokens []xml.Token
+
+//
+// ContentProvider
+//
+
+func (p *jsonContentProvider) Get(url string) (*FantasyContent, error) {
+	return p.get(url, trace{})
+}
+
+// get requests the content for the URL in JSON, recording the request and the
+// decoding of its response in the trace.
+func (p *jsonContentProvider) get(url string, t trace) (*FantasyContent, error) {
+	return getContent(p.client, jsonURL(url), jsonDecoder, t)
+}
+
+// revalidate makes a conditional request for the given URL in JSON using the
+// validators of previously retrieved content. If the API responds that the
+// content has not been modified, the previous content is returned.
+func (p *jsonContentProvider) revalidate(
+	url string,
+	content *FantasyContent,
+	t trace) (*FantasyContent, error) {
+
+	return revalidateContent(p.client, jsonURL(url), content, jsonDecoder, t)
+}
+
+func (p *jsonContentProvider) RequestCount() int {
+	return p.client.RequestCount()
+}
+
+// jsonURL adds the "format=json" query parameter to the URL, unless it
+// already specifies a format.
+func jsonURL(url string) string {
+	query := strings.Index(url, "?")
+	if query < 0 {
+		return url + "?format=json"
+	}
+	for _, param := range strings.Split(url[query+1:], "&") {
+		if strings.HasPrefix(param, "format=") {
+			return url
+		}
+	}
+	return url + "&format=json"
+}
+
+// newJSONDecoder converts a JSON response of the API to XML tokens, returning
+// a decoder that reads them in the same way as an XML response.
+func newJSONDecoder(body io.Reader) (*xml.Decoder, error) {
+	converter := &jsonConverter{decoder: json.NewDecoder(body)}
+	converter.decoder.UseNumber()
+	if err := converter.value(""); err != nil {
+		return nil, err
+	}
+
+	tokens := xmlTokens(converter.tokens)
+	return xml.NewTokenDecoder(&tokens), nil
+}
+
+// value converts the next JSON value into the contents of an element with the
+// given name. When the name is empty, the contents are added to the current
+// element instead.
+func (c *jsonConverter) value(name string) error {
+	token, err := c.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		c.start(name)
+		for c.decoder.More() {
+			child := ""
+			if token == '{' {
+				key, err := c.decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+				if isIndex(child) {
+					child = ""
+				}
+			}
+			if err := c.value(child); err != nil {
+				return err
+			}
+		}
+		if _, err := c.decoder.Token(); err != nil {
+			return err
+		}
+		c.end(name)
+	case string:
+		c.text(name, token)
+	case json.Number:
+		c.text(name, token.String())
+	case bool:
+		c.text(name, strconv.FormatBool(token))
+	}
+	// null values are omitted
+	return nil
+}
+
+// start opens an element with the given name, if not empty
+func (c *jsonConverter) start(name string) {
+	if name != "" {
+		c.tokens = append(c.tokens, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// end closes the element with the given name, if not empty
+func (c *jsonConverter) end(name string) {
+	if name != "" {
+		c.tokens = append(c.tokens, xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// text adds an element with the given name containing the text
+func (c *jsonConverter) text(name string, text string) {
+	c.start(name)
+	c.tokens = append(c.tokens, xml.CharData(text))
+	c.end(name)
+}
+
+// isIndex reports whether the JSON key is the index of an element in a list
+func isIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Token returns the next token, or io.EOF once every token has been read.
+func (t *xmlTokens) Token() (xml.Token, error) {
+	if len(*t) == 0 {
+		return nil, io.EOF
+	}
+	token := (*t)[0]
+	*t = (*t)[1:]
+	return token, nil
+}
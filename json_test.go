@@ -0,0 +1,433 @@
+package goff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test jsonContentProvider
+//
+
+func TestJSONContentProviderRequestsJSON(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueJSONContent)}
+	client := NewFormatClient(httpClient, FormatJSON)
+
+	_, err := client.GetLeagueMetadata("223.l.431")
+	if err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+
+	assertStringEquals(
+		t,
+		YahooBaseURL+"/league/223.l.431/metadata?format=json",
+		httpClient.LastURL)
+}
+
+func TestJSONContentProviderInvalidJSON(t *testing.T) {
+	client := NewFormatClient(
+		&mockHTTPClient{Response: mockResponse(`{"fantasy_content": [`)},
+		FormatJSON)
+
+	_, err := client.GetFantasyContent("http://example.com")
+	if err == nil {
+		t.Fatalf("no error returned for invalid JSON")
+	}
+}
+
+func TestJSONContentProviderCached(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueJSONContent)}
+	client := NewCachedFormatClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		httpClient,
+		FormatJSON)
+
+	client.GetLeagueMetadata("223.l.431")
+	league, err := client.GetLeagueMetadata("223.l.431")
+	if err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+
+	assertStringEquals(t, expectedLeague.LeagueKey, league.LeagueKey)
+	assertIntEquals(t, 1, httpClient.RequestCount)
+}
+
+func TestJSONURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{
+			"http://example.com/league",
+			"http://example.com/league?format=json",
+		},
+		{
+			"http://example.com/league?a=b",
+			"http://example.com/league?a=b&format=json",
+		},
+		{
+			"http://example.com/league?format=xml",
+			"http://example.com/league?format=xml",
+		},
+		{
+			"http://example.com/team;out=stats?a=b&c",
+			"http://example.com/team;out=stats?a=b&c&format=json",
+		},
+	}
+	for _, test := range tests {
+		assertStringEquals(t, test.expected, jsonURL(test.url))
+	}
+}
+
+//
+// Test JSON and XML parity
+//
+
+func TestJSONParityLeague(t *testing.T) {
+	assertFormatParity(t, leagueXMLContent, leagueJSONContent)
+}
+
+func TestJSONParityTeam(t *testing.T) {
+	assertFormatParity(t, teamXMLContent, teamJSONContent)
+}
+
+func TestJSONParityStandings(t *testing.T) {
+	content := assertFormatParity(t, standingsXMLContent, standingsJSONContent)
+
+	standings := content.League.Standings
+	if len(standings) != 2 {
+		t.Fatalf("Unexpected standings: %+v", standings)
+	}
+	assertIntEquals(t, 1, standings[0].TeamStandings.Rank)
+	assertIntEquals(t, 10, standings[0].TeamStandings.Record.Wins)
+	assertFloatEquals(t, 1500.25, standings[0].TeamPoints.Total)
+	assertStringEquals(t, "One", standings[0].Managers[0].Nickname)
+	assertStringEquals(t, "Team 2", standings[1].Name)
+}
+
+func TestJSONParityRoster(t *testing.T) {
+	content := assertFormatParity(t, rosterXMLContent, rosterJSONContent)
+
+	players := content.Team.Roster.Players
+	if len(players) != 2 {
+		t.Fatalf("Unexpected players: %+v", players)
+	}
+	assertStringEquals(t, "Adrian Peterson", players[0].Name.Full)
+	assertStringEquals(t, "RB", players[0].SelectedPosition.Position)
+	assertIntEquals(t, 16, players[0].SelectedPosition.Week)
+	assertStringEquals(t, "BN", players[1].SelectedPosition.Position)
+	assertIntEquals(t, 16, content.Team.Roster.Week)
+}
+
+//
+// Test Data
+//
+
+// assertFormatParity decodes the XML and JSON content and checks that they
+// produce the same fantasy content.
+func assertFormatParity(
+	t *testing.T,
+	xmlContent string,
+	jsonContent string) *FantasyContent {
+
+	expected, err := NewFormatClient(
+		&mockHTTPClient{Response: mockResponse(xmlContent)},
+		FormatXML).GetFantasyContent("http://example.com")
+	if err != nil {
+		t.Fatalf("error decoding XML: %s", err)
+	}
+
+	actual, err := NewFormatClient(
+		&mockHTTPClient{Response: mockResponse(jsonContent)},
+		FormatJSON).GetFantasyContent("http://example.com")
+	if err != nil {
+		t.Fatalf("error decoding JSON: %s", err)
+	}
+
+	// The namespace of the XML response isn't included in JSON
+	expected.XMLName.Space = ""
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("JSON content does not match XML content\n\t"+
+			"expected: %+v\n\tactual: %+v",
+			expected,
+			actual)
+	}
+	return actual
+}
+
+var leagueJSONContent = `{"fantasy_content": {
+  "xml:lang": "en-US",
+  "yahoo:uri": "/fantasy/v2/league/223.l.431",
+  "league": [{
+    "league_key": "` + expectedLeague.LeagueKey + `",
+    "league_id": "` + fmt.Sprintf("%d", expectedLeague.LeagueID) + `",
+    "name": "` + expectedLeague.Name + `",
+    "url": "http://football.fantasysports.yahoo.com/archive/pnfl/2009/431",
+    "draft_status": "postdraft",
+    "num_teams": 14,
+    "edit_key": 17,
+    "weekly_deadline": null,
+    "league_update_timestamp": "1262595518",
+    "scoring_type": "head",
+    "current_week": "` + fmt.Sprintf("%d", expectedLeague.CurrentWeek) + `",
+    "start_week": "` + fmt.Sprintf("%d", expectedLeague.StartWeek) + `",
+    "end_week": "` + fmt.Sprintf("%d", expectedLeague.EndWeek) + `",
+    "is_finished": ` + fmt.Sprintf("%t", expectedLeague.IsFinished) + `
+  }],
+  "time": "181.80584907532ms",
+  "copyright": "Data provided by Yahoo! and STATS, LLC",
+  "refresh_rate": "60"
+}}`
+
+var teamJSONContent = `{"fantasy_content": {
+  "xml:lang": "en-US",
+  "yahoo:uri": "/fantasy/v2/team/223.l.431.t.1",
+  "team": [[
+    {"team_key": "` + expectedTeam.TeamKey + `"},
+    {"team_id": "` + fmt.Sprintf("%d", expectedTeam.TeamID) + `"},
+    {"name": "` + expectedTeam.Name + `"},
+    [],
+    {"url": "http://football.fantasysports.yahoo.com/archive/pnfl/2009/431/1"},
+    {"team_logos": [{"team_logo": {
+      "size": "` + expectedTeam.TeamLogos[0].Size + `",
+      "url": "` + expectedTeam.TeamLogos[0].URL + `"
+    }}]},
+    [],
+    {"division_id": "2"},
+    {"faab_balance": "22"},
+    {"managers": [{"manager": {
+      "manager_id": "` + fmt.Sprintf("%d", expectedTeam.Managers[0].ManagerID) + `",
+      "nickname": "` + expectedTeam.Managers[0].Nickname + `",
+      "guid": "` + expectedTeam.Managers[0].GUID + `"
+    }}]}
+  ], {
+    "team_points": {
+      "coverage_type": "` + expectedTeam.TeamPoints.CoverageType + `",
+      "week": "` + fmt.Sprintf("%d", expectedTeam.TeamPoints.Week) + `",
+      "total": "` + fmt.Sprintf("%f", expectedTeam.TeamPoints.Total) + `"
+    },
+    "team_projected_points": {
+      "coverage_type": "` + expectedTeam.TeamProjectedPoints.CoverageType + `",
+      "week": "` + fmt.Sprintf("%d", expectedTeam.TeamProjectedPoints.Week) + `",
+      "total": "` + fmt.Sprintf("%f", expectedTeam.TeamProjectedPoints.Total) + `"
+    }
+  }],
+  "time": "426.26690864563ms",
+  "copyright": "Data provided by Yahoo! and STATS, LLC",
+  "refresh_rate": "60"
+}}`
+
+var standingsXMLContent = `<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xml:lang="en-US" yahoo:uri="http://fantasysports.yahooapis.com/fantasy/v2/league/223.l.431/standings" xmlns:yahoo="http://www.yahooapis.com/v1/base.rng" xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <league>
+    <league_key>223.l.431</league_key>
+    <name>League Name</name>
+    <standings>
+      <teams count="2">
+        <team>
+          <team_key>223.l.431.t.1</team_key>
+          <team_id>1</team_id>
+          <name>Team 1</name>
+          <managers>
+            <manager>
+              <manager_id>1</manager_id>
+              <nickname>One</nickname>
+            </manager>
+          </managers>
+          <team_points>
+            <coverage_type>season</coverage_type>
+            <season>2009</season>
+            <total>1500.25</total>
+          </team_points>
+          <team_standings>
+            <rank>1</rank>
+            <outcome_totals>
+              <wins>10</wins>
+              <losses>3</losses>
+              <ties>0</ties>
+            </outcome_totals>
+            <points_for>1500.25</points_for>
+            <points_against>1200.5</points_against>
+          </team_standings>
+        </team>
+        <team>
+          <team_key>223.l.431.t.2</team_key>
+          <team_id>2</team_id>
+          <name>Team 2</name>
+          <team_points>
+            <coverage_type>season</coverage_type>
+            <season>2009</season>
+            <total>1300</total>
+          </team_points>
+          <team_standings>
+            <rank/>
+            <outcome_totals>
+              <wins>3</wins>
+              <losses>10</losses>
+              <ties>0</ties>
+            </outcome_totals>
+            <points_for>1300</points_for>
+            <points_against>1450.75</points_against>
+          </team_standings>
+        </team>
+      </teams>
+    </standings>
+  </league>
+</fantasy_content>`
+
+var standingsJSONContent = `{"fantasy_content": {
+  "xml:lang": "en-US",
+  "yahoo:uri": "/fantasy/v2/league/223.l.431/standings",
+  "league": [{
+    "league_key": "223.l.431",
+    "name": "League Name"
+  }, {
+    "standings": [{"teams": {
+      "0": {"team": [[
+        {"team_key": "223.l.431.t.1"},
+        {"team_id": "1"},
+        {"name": "Team 1"},
+        [],
+        {"managers": [{"manager": {"manager_id": "1", "nickname": "One"}}]}
+      ], {
+        "team_points": {
+          "coverage_type": "season",
+          "season": "2009",
+          "total": "1500.25"
+        }
+      }, {
+        "team_standings": {
+          "rank": 1,
+          "outcome_totals": {"wins": 10, "losses": 3, "ties": 0},
+          "points_for": "1500.25",
+          "points_against": 1200.5
+        }
+      }]},
+      "1": {"team": [[
+        {"team_key": "223.l.431.t.2"},
+        {"team_id": "2"},
+        {"name": "Team 2"}
+      ], {
+        "team_points": {
+          "coverage_type": "season",
+          "season": "2009",
+          "total": "1300"
+        }
+      }, {
+        "team_standings": {
+          "rank": "",
+          "outcome_totals": {"wins": "3", "losses": "10", "ties": 0},
+          "points_for": "1300",
+          "points_against": "1450.75"
+        }
+      }]},
+      "count": 2
+    }}]
+  }],
+  "time": "88.4530544281ms",
+  "copyright": "Data provided by Yahoo! and STATS, LLC",
+  "refresh_rate": "60"
+}}`
+
+var rosterXMLContent = `<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xml:lang="en-US" yahoo:uri="http://fantasysports.yahooapis.com/fantasy/v2/team/223.l.431.t.1/roster;week=16" xmlns:yahoo="http://www.yahooapis.com/v1/base.rng" xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <team>
+    <team_key>223.l.431.t.1</team_key>
+    <team_id>1</team_id>
+    <roster>
+      <coverage_type>week</coverage_type>
+      <week>16</week>
+      <players count="2">
+        <player>
+          <player_key>223.p.8261</player_key>
+          <player_id>8261</player_id>
+          <name>
+            <full>Adrian Peterson</full>
+            <first>Adrian</first>
+            <last>Peterson</last>
+          </name>
+          <editorial_team_abbr>Min</editorial_team_abbr>
+          <display_position>RB</display_position>
+          <selected_position>
+            <coverage_type>week</coverage_type>
+            <week>16</week>
+            <position>RB</position>
+          </selected_position>
+        </player>
+        <player>
+          <player_key>223.p.5479</player_key>
+          <player_id>5479</player_id>
+          <name>
+            <full>Tom Brady</full>
+            <first>Tom</first>
+            <last>Brady</last>
+          </name>
+          <status>IR</status>
+          <editorial_team_abbr>NE</editorial_team_abbr>
+          <display_position>QB</display_position>
+          <selected_position>
+            <coverage_type>week</coverage_type>
+            <week>16</week>
+            <position>BN</position>
+          </selected_position>
+        </player>
+      </players>
+    </roster>
+  </team>
+</fantasy_content>`
+
+var rosterJSONContent = `{"fantasy_content": {
+  "xml:lang": "en-US",
+  "yahoo:uri": "/fantasy/v2/team/223.l.431.t.1/roster;week=16",
+  "team": [[
+    {"team_key": "223.l.431.t.1"},
+    {"team_id": "1"}
+  ], {
+    "roster": {
+      "coverage_type": "week",
+      "week": "16",
+      "0": {"players": {
+        "0": {"player": [[
+          {"player_key": "223.p.8261"},
+          {"player_id": "8261"},
+          {"name": {
+            "full": "Adrian Peterson",
+            "first": "Adrian",
+            "last": "Peterson"
+          }},
+          {"editorial_team_abbr": "Min"},
+          {"display_position": "RB"}
+        ], {
+          "selected_position": [
+            {"coverage_type": "week"},
+            {"week": "16"},
+            {"position": "RB"}
+          ]
+        }]},
+        "1": {"player": [[
+          {"player_key": "223.p.5479"},
+          {"player_id": "5479"},
+          {"name": {"full": "Tom Brady", "first": "Tom", "last": "Brady"}},
+          {"status": "IR"},
+          {"editorial_team_abbr": "NE"},
+          {"display_position": "QB"}
+        ], {
+          "selected_position": [
+            {"coverage_type": "week"},
+            {"week": 16},
+            {"position": "BN"}
+          ]
+        }]},
+        "count": 2
+      }}
+    }
+  }],
+  "time": "60.2998733521ms",
+  "copyright": "Data provided by Yahoo! and STATS, LLC",
+  "refresh_rate": "60"
+}}`
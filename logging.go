@@ -0,0 +1,105 @@
+package goff
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Logger records structured log messages, where args are alternating keys
+// and values. A *slog.Logger from the log/slog package implements Logger.
+// Implementations must be safe for concurrent use.
+//
+// Set the Logger of a Client to log each call to GetFantasyContent or one of
+// the convenience functions once it completes. Each message includes the
+// following keys, when known: "call", "url", "resource", "status",
+// "duration", "retries", "cache", "bytes", and "error". Credentials in URLs
+// and errors are redacted using Redact.
+type Logger interface {
+	// Logs a request that succeeded
+	Info(msg string, args ...interface{})
+	// Logs a request that failed
+	Error(msg string, args ...interface{})
+}
+
+// redactedPattern matches the values of OAuth signatures, tokens, and
+// secrets in URLs, headers, and form values.
+var redactedPattern = regexp.MustCompile(
+	`(?i)\b((?:oauth_|client_|consumer_|access_|refresh_)?` +
+		`(?:signature|token|token_secret|secret|verifier|session_handle)` +
+		`\s*=\s*"?)[^"&;,\s]*`)
+
+// requestLog records what happened while getting the content for a single
+// call to a Client.
+type requestLog struct {
+	logger     Logger
+	name       string
+	url        string
+	start      time.Time
+	attributes map[string]interface{}
+}
+
+// Redact returns the given URL, header, or error message with the values of
+// any OAuth signatures, tokens, and secrets replaced by "REDACTED".
+func Redact(s string) string {
+	return redactedPattern.ReplaceAllString(s, "${1}REDACTED")
+}
+
+// newRequestLog starts recording a call with the given name for the URL.
+func newRequestLog(logger Logger, name string, url string) *requestLog {
+	return &requestLog{
+		logger:     logger,
+		name:       name,
+		url:        url,
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+}
+
+// write logs the call, recording the error if it is not nil.
+func (l *requestLog) write(err error) {
+	args := []interface{}{
+		"call", l.name,
+		"url", Redact(l.url),
+		"resource", l.attributes[attributeResource],
+	}
+	if status, ok := l.attributes[attributeStatusCode]; ok {
+		args = append(args, "status", status)
+	}
+	args = append(args, "duration", time.Since(l.start))
+	if retries, ok := l.attributes[attributeRetries]; ok {
+		args = append(args, "retries", retries)
+	}
+	if cache := l.cacheOutcome(); cache != "" {
+		args = append(args, "cache", cache)
+	}
+	if bytes, ok := l.attributes[attributeBytes]; ok {
+		args = append(args, "bytes", bytes)
+	}
+
+	if err != nil {
+		args = append(args, "error", Redact(err.Error()))
+		l.logger.Error("fantasy sports API request failed", args...)
+		return
+	}
+	l.logger.Info("fantasy sports API request", args...)
+}
+
+// cacheOutcome describes how the cache was used for the call: "hit", "miss",
+// "stale", or "revalidated". An empty string is returned if no cache was
+// used.
+func (l *requestLog) cacheOutcome() string {
+	hit, ok := l.attributes[attributeCacheHit]
+	switch {
+	case !ok:
+		return ""
+	case hit == true:
+		return "hit"
+	case l.attributes[attributeCacheStale] == true:
+		return "stale"
+	case l.attributes[attributeStatusCode] == http.StatusNotModified:
+		return "revalidated"
+	default:
+		return "miss"
+	}
+}
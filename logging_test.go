@@ -0,0 +1,161 @@
+package goff
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test Redact
+//
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"http://example.com/league?oauth_token=abc&oauth_signature=d%3D",
+			"http://example.com/league?oauth_token=REDACTED&" +
+				"oauth_signature=REDACTED",
+		},
+		{
+			`OAuth oauth_consumer_key="key", oauth_signature="abc"`,
+			`OAuth oauth_consumer_key="key", oauth_signature="REDACTED"`,
+		},
+		{
+			"oauth_token_secret=abc&oauth_session_handle=def",
+			"oauth_token_secret=REDACTED&oauth_session_handle=REDACTED",
+		},
+		{
+			"client_secret=abc",
+			"client_secret=REDACTED",
+		},
+		{
+			"http://example.com/team/223.l.431.t.1;out=stats",
+			"http://example.com/team/223.l.431.t.1;out=stats",
+		},
+	}
+	for _, test := range tests {
+		assertStringEquals(t, test.expected, Redact(test.input))
+	}
+}
+
+//
+// Test Client logging
+//
+
+func TestLoggedClientCacheMiss(t *testing.T) {
+	logger := &mockLogger{}
+	response := mockResponse(leagueXMLContent)
+	response.StatusCode = http.StatusOK
+	client := NewCachedClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		&mockHTTPClient{Response: response})
+	client.Logger = logger
+
+	client.GetLeagueMetadata("223.l.431")
+
+	if len(logger.info) != 1 || len(logger.errors) != 0 {
+		t.Fatalf("Unexpected messages logged: %+v", logger)
+	}
+	message := logger.info[0]
+	assertStringEquals(t, "goff.GetLeagueMetadata", message["call"].(string))
+	assertStringEquals(t, "league", message["resource"].(string))
+	assertStringEquals(t, "miss", message["cache"].(string))
+	assertIntEquals(t, http.StatusOK, message["status"].(int))
+	assertIntEquals(t, 0, message["retries"].(int))
+	assertIntEquals(t, len(leagueXMLContent), message["bytes"].(int))
+	if _, ok := message["duration"].(time.Duration); !ok {
+		t.Fatalf("Duration not logged: %+v", message)
+	}
+}
+
+func TestLoggedClientCacheHit(t *testing.T) {
+	logger := &mockLogger{}
+	client := NewCachedClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)})
+
+	client.GetLeagueMetadata("223.l.431")
+	client.Logger = logger
+	client.GetLeagueMetadata("223.l.431")
+
+	message := logger.info[0]
+	assertStringEquals(t, "hit", message["cache"].(string))
+	if _, ok := message["status"]; ok {
+		t.Fatalf("Status logged for cached content: %+v", message)
+	}
+}
+
+func TestLoggedClientError(t *testing.T) {
+	logger := &mockLogger{}
+	client := NewClient(&mockHTTPClient{
+		Error:      errors.New("invalid oauth_signature=abc"),
+		ErrorCount: 1,
+	})
+	client.Logger = logger
+
+	client.GetFantasyContent("http://example.com?oauth_token=abc")
+
+	if len(logger.errors) != 1 || len(logger.info) != 0 {
+		t.Fatalf("Unexpected messages logged: %+v", logger)
+	}
+	message := logger.errors[0]
+	assertStringEquals(
+		t,
+		"http://example.com?oauth_token=REDACTED",
+		message["url"].(string))
+	assertStringEquals(
+		t,
+		"invalid oauth_signature=REDACTED",
+		message["error"].(string))
+	if _, ok := message["cache"]; ok {
+		t.Fatalf("Cache outcome logged without a cache: %+v", message)
+	}
+}
+
+func TestLoggedClientSlog(t *testing.T) {
+	var output bytes.Buffer
+	client := mockClient(&FantasyContent{}, nil)
+	client.Logger = slog.New(slog.NewTextHandler(&output, nil))
+
+	client.GetAllTeams("223.l.431")
+
+	if !strings.Contains(output.String(), "call=goff.GetAllTeams") {
+		t.Fatalf("Request not logged: %s", output.String())
+	}
+}
+
+//
+// Mocks
+//
+
+// mockLogger records the key-value pairs of every message logged
+type mockLogger struct {
+	info   []map[string]interface{}
+	errors []map[string]interface{}
+}
+
+func (m *mockLogger) Info(msg string, args ...interface{}) {
+	m.info = append(m.info, mockLogMessage(args))
+}
+
+func (m *mockLogger) Error(msg string, args ...interface{}) {
+	m.errors = append(m.errors, mockLogMessage(args))
+}
+
+func mockLogMessage(args []interface{}) map[string]interface{} {
+	message := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		message[args[i].(string)] = args[i+1]
+	}
+	return message
+}
@@ -0,0 +1,230 @@
+package goff
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics records measurements of the requests made to the Yahoo fantasy
+// sports API. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Records a single request made for the given type of resource, e.g.
+	// "league", along with the status code of the response, or zero if no
+	// response was received, how long the request took, and the error
+	// returned, if any
+	ObserveRequest(
+		resource string,
+		statusCode int,
+		duration time.Duration,
+		err error)
+}
+
+// DefaultLatencyBuckets are the upper bounds of the latency histograms kept
+// by RequestMetrics.
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// RequestMetrics implements Metrics by aggregating the measurements for each
+// type of resource in memory.
+type RequestMetrics struct {
+	mutex     sync.Mutex
+	buckets   []time.Duration
+	resources map[string]*ResourceMetrics
+}
+
+// ResourceMetrics describes the requests made for a single type of resource.
+type ResourceMetrics struct {
+	// Amount of requests made
+	Requests int64
+	// Amount of requests that returned an error or an error status code
+	Errors int64
+	// Amount of responses received for each status code
+	StatusCodes map[int]int64
+	// Distribution of the time taken by each request
+	Latency LatencyHistogram
+}
+
+// LatencyHistogram counts durations in buckets with increasing upper bounds.
+type LatencyHistogram struct {
+	// Upper bounds of each bucket
+	Buckets []time.Duration
+	// Amount of durations within each bucket and not within any previous
+	// bucket. Contains a final count for durations larger than every bound.
+	Counts []int64
+	// Amount of durations observed
+	Count int64
+	// Sum of all durations observed
+	Sum time.Duration
+}
+
+// measuredHTTPClient implements HTTPRequestClient and reports every request
+// it makes to Metrics.
+type measuredHTTPClient struct {
+	metrics Metrics
+	client  HTTPClient
+	now     func() time.Time
+}
+
+// NewRequestMetrics creates RequestMetrics that keep latency histograms
+// using DefaultLatencyBuckets.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{
+		buckets:   DefaultLatencyBuckets,
+		resources: make(map[string]*ResourceMetrics),
+	}
+}
+
+// NewMeasuredClient returns a HTTPClient that makes requests using the given
+// client and reports each of them to the given Metrics. The returned client
+// can be passed to NewClient or NewCachedClient. Each retry of a failed
+// request is reported separately.
+func NewMeasuredClient(client HTTPClient, metrics Metrics) HTTPRequestClient {
+	return &measuredHTTPClient{
+		metrics: metrics,
+		client:  client,
+		now:     time.Now,
+	}
+}
+
+// ObserveRequest records a single request for the given type of resource.
+func (m *RequestMetrics) ObserveRequest(
+	resource string,
+	statusCode int,
+	duration time.Duration,
+	err error) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	metrics, ok := m.resources[resource]
+	if !ok {
+		metrics = &ResourceMetrics{
+			StatusCodes: make(map[int]int64),
+			Latency:     newLatencyHistogram(m.buckets),
+		}
+		m.resources[resource] = metrics
+	}
+
+	metrics.Requests++
+	if err != nil || statusCode >= http.StatusBadRequest {
+		metrics.Errors++
+	}
+	if statusCode != 0 {
+		metrics.StatusCodes[statusCode]++
+	}
+	metrics.Latency.observe(duration)
+}
+
+// Resources returns a copy of the metrics for each type of resource that has
+// been requested.
+func (m *RequestMetrics) Resources() map[string]ResourceMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	resources := make(map[string]ResourceMetrics, len(m.resources))
+	for resource, metrics := range m.resources {
+		resources[resource] = metrics.copy()
+	}
+	return resources
+}
+
+// ResourceTypes returns the sorted types of resources that have been
+// requested.
+func (m *RequestMetrics) ResourceTypes() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	types := make([]string, 0, len(m.resources))
+	for resource := range m.resources {
+		types = append(types, resource)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// copy returns a deep copy of the metrics
+func (r *ResourceMetrics) copy() ResourceMetrics {
+	metrics := *r
+	metrics.StatusCodes = make(map[int]int64, len(r.StatusCodes))
+	for statusCode, count := range r.StatusCodes {
+		metrics.StatusCodes[statusCode] = count
+	}
+	metrics.Latency.Counts = append([]int64(nil), r.Latency.Counts...)
+	return metrics
+}
+
+// newLatencyHistogram creates an empty histogram with the given bucket
+// bounds.
+func newLatencyHistogram(buckets []time.Duration) LatencyHistogram {
+	return LatencyHistogram{
+		Buckets: buckets,
+		Counts:  make([]int64, len(buckets)+1),
+	}
+}
+
+// observe counts the given duration in the first bucket containing it.
+func (h *LatencyHistogram) observe(duration time.Duration) {
+	index := sort.Search(len(h.Buckets), func(i int) bool {
+		return duration <= h.Buckets[i]
+	})
+	h.Counts[index]++
+	h.Count++
+	h.Sum += duration
+}
+
+// Mean returns the average of all durations observed.
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+//
+// HTTPClient
+//
+
+// Get makes a GET request to the given URL and reports it to the client's
+// Metrics.
+func (c *measuredHTTPClient) Get(url string) (*http.Response, error) {
+	return c.measure(url, func() (*http.Response, error) {
+		return c.client.Get(url)
+	})
+}
+
+// Do sends the given request and reports it to the client's Metrics.
+func (c *measuredHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	if !canSend(c.client, request) {
+		return nil, ErrRequestUnsupported
+	}
+	return c.measure(request.URL.String(), func() (*http.Response, error) {
+		return do(c.client, request)
+	})
+}
+
+// measure times the given request for the URL and reports the result
+func (c *measuredHTTPClient) measure(
+	url string,
+	request func() (*http.Response, error)) (*http.Response, error) {
+
+	start := c.now()
+	response, err := request()
+	duration := c.now().Sub(start)
+
+	statusCode := 0
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	c.metrics.ObserveRequest(ResourceType(url), statusCode, duration, err)
+	return response, err
+}
@@ -0,0 +1,195 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// Test RequestMetrics
+//
+
+func TestRequestMetricsObserveRequest(t *testing.T) {
+	metrics := NewRequestMetrics()
+
+	metrics.ObserveRequest("league", http.StatusOK, 10*time.Millisecond, nil)
+	metrics.ObserveRequest("league", http.StatusOK, 300*time.Millisecond, nil)
+	metrics.ObserveRequest("league", http.StatusNotFound, time.Minute, nil)
+	metrics.ObserveRequest("team", 0, 75*time.Millisecond, errors.New("error"))
+
+	resources := metrics.Resources()
+	league := resources["league"]
+	assertInt64Equals(t, 3, league.Requests)
+	assertInt64Equals(t, 1, league.Errors)
+	assertInt64Equals(t, 2, league.StatusCodes[http.StatusOK])
+	assertInt64Equals(t, 1, league.StatusCodes[http.StatusNotFound])
+	assertInt64Equals(t, 3, league.Latency.Count)
+
+	expectedCounts := []int64{1, 0, 0, 1, 0, 0, 0, 0, 1}
+	if !reflect.DeepEqual(expectedCounts, league.Latency.Counts) {
+		t.Fatalf("Unexpected latency histogram\n\texpected: %+v\n\t"+
+			"actual: %+v",
+			expectedCounts,
+			league.Latency.Counts)
+	}
+
+	expectedSum := 10*time.Millisecond + 300*time.Millisecond + time.Minute
+	if league.Latency.Sum != expectedSum {
+		t.Fatalf("Unexpected latency sum\n\texpected: %s\n\tactual: %s",
+			expectedSum,
+			league.Latency.Sum)
+	}
+
+	if league.Latency.Mean() != expectedSum/3 {
+		t.Fatalf("Unexpected mean latency: %s", league.Latency.Mean())
+	}
+
+	team := resources["team"]
+	assertInt64Equals(t, 1, team.Requests)
+	assertInt64Equals(t, 1, team.Errors)
+	assertIntEquals(t, 0, len(team.StatusCodes))
+	assertInt64Equals(t, 1, team.Latency.Counts[1])
+}
+
+func TestRequestMetricsResourcesIsCopy(t *testing.T) {
+	metrics := NewRequestMetrics()
+	metrics.ObserveRequest("league", http.StatusOK, time.Millisecond, nil)
+
+	resources := metrics.Resources()
+	league := resources["league"]
+	league.StatusCodes[http.StatusOK] = 100
+	league.Latency.Counts[0] = 100
+
+	actual := metrics.Resources()["league"]
+	assertInt64Equals(t, 1, actual.StatusCodes[http.StatusOK])
+	assertInt64Equals(t, 1, actual.Latency.Counts[0])
+}
+
+func TestRequestMetricsResourceTypes(t *testing.T) {
+	metrics := NewRequestMetrics()
+	metrics.ObserveRequest("users", http.StatusOK, time.Millisecond, nil)
+	metrics.ObserveRequest("league", http.StatusOK, time.Millisecond, nil)
+	metrics.ObserveRequest("team", http.StatusOK, time.Millisecond, nil)
+
+	expected := []string{"league", "team", "users"}
+	actual := metrics.ResourceTypes()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Unexpected resource types\n\texpected: %+v\n\tactual: %+v",
+			expected,
+			actual)
+	}
+}
+
+func TestLatencyHistogramMeanEmpty(t *testing.T) {
+	histogram := newLatencyHistogram(DefaultLatencyBuckets)
+	if histogram.Mean() != 0 {
+		t.Fatalf("Unexpected mean of empty histogram: %s", histogram.Mean())
+	}
+}
+
+//
+// Test measuredHTTPClient
+//
+
+func TestMeasuredHTTPClientGet(t *testing.T) {
+	metrics := NewRequestMetrics()
+	expected := &http.Response{StatusCode: http.StatusOK}
+	client := NewMeasuredClient(&mockHTTPClient{Response: expected}, metrics)
+
+	response, err := client.Get(YahooBaseURL + "/league/223.l.431/teams")
+	if err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("received unexpected response from client")
+	}
+
+	league := metrics.Resources()["league"]
+	assertInt64Equals(t, 1, league.Requests)
+	assertInt64Equals(t, 0, league.Errors)
+	assertInt64Equals(t, 1, league.StatusCodes[http.StatusOK])
+}
+
+func TestMeasuredHTTPClientDo(t *testing.T) {
+	metrics := NewRequestMetrics()
+	httpClient := &mockHTTPClient{
+		Response:   nil,
+		Error:      errors.New("error"),
+		ErrorCount: 1,
+	}
+	client := NewMeasuredClient(httpClient, metrics)
+
+	request, _ := http.NewRequest("GET", YahooBaseURL+"/team/223.l.431.t.1", nil)
+	_, err := client.Do(request)
+	if err == nil {
+		t.Fatal("error not returned from client")
+	}
+
+	if httpClient.LastRequest != request {
+		t.Fatalf("request not sent to client")
+	}
+
+	team := metrics.Resources()["team"]
+	assertInt64Equals(t, 1, team.Requests)
+	assertInt64Equals(t, 1, team.Errors)
+}
+
+func TestMeasuredHTTPClientDoUnsupported(t *testing.T) {
+	metrics := NewRequestMetrics()
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	client := NewMeasuredClient(&mockGetHTTPClient{httpClient}, metrics)
+
+	request, _ := http.NewRequest("POST", YahooBaseURL+"/team/223.l.431.t.1", nil)
+	if _, err := client.Do(request); err != ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for unsupported request: %v", err)
+	}
+
+	assertStringEquals(t, "", httpClient.LastURL)
+	assertIntEquals(t, 0, len(metrics.Resources()))
+}
+
+func TestMeasuredHTTPClientDuration(t *testing.T) {
+	metrics := NewRequestMetrics()
+	client := NewMeasuredClient(
+		&mockHTTPClient{Response: &http.Response{}},
+		metrics).(*measuredHTTPClient)
+
+	now := time.Unix(1408281677, 0)
+	client.now = func() time.Time {
+		now = now.Add(200 * time.Millisecond)
+		return now
+	}
+	client.Get(YahooBaseURL + "/users;use_login=1/games")
+
+	users := metrics.Resources()["users"]
+	if users.Latency.Sum != 200*time.Millisecond {
+		t.Fatalf("Unexpected request duration: %s", users.Latency.Sum)
+	}
+}
+
+func TestMeasuredHTTPClientConcurrentUse(t *testing.T) {
+	metrics := NewRequestMetrics()
+	client := NewClient(NewMeasuredClient(
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			return mockResponse(leagueXMLContent), nil
+		}),
+		metrics))
+
+	var wait sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			client.GetLeagueMetadata("223.l.431")
+		}()
+	}
+	wait.Wait()
+
+	assertIntEquals(t, 50, client.RequestCount())
+	assertInt64Equals(t, 50, metrics.Resources()["league"].Requests)
+}
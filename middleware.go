@@ -0,0 +1,152 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrMiddlewareUnsupported is returned by Client.Use when the client's
+// Provider was not created by this package, so its requests can't be run
+// through middleware.
+var ErrMiddlewareUnsupported = errors.New(
+	"middleware is not supported by the content provider")
+
+// Middleware observes or modifies the requests made to the Yahoo fantasy
+// sports API and their results. Any of its hooks can be nil.
+//
+// Hooks are run in the order the middleware is given to NewMiddlewareClient
+// or Client.Use before a request is sent, and in reverse order once it completes, so the
+// first middleware sees the final result of the request. When the client
+// retries failed requests, each attempt passes through the middleware.
+type Middleware struct {
+	// Called before the request is sent. The request can be modified, e.g. to
+	// add headers. Returning an error stops the request from being sent and
+	// passes the error to the OnError hooks of the middleware that already
+	// ran.
+	BeforeRequest func(request *http.Request) error
+	// Called with the response received for the request. The response
+	// returned is passed to the remaining middleware and then to the client.
+	// Returning an error instead passes it to the OnError hooks of the
+	// remaining middleware.
+	AfterResponse func(
+		request *http.Request,
+		response *http.Response) (*http.Response, error)
+	// Called when the request, or a hook of later middleware, fails. Returning
+	// a response and a nil error recovers from the failure.
+	OnError func(
+		request *http.Request,
+		err error) (*http.Response, error)
+}
+
+// middlewareHTTPClient implements HTTPRequestClient and runs every request
+// through a chain of Middleware.
+type middlewareHTTPClient struct {
+	middleware []Middleware
+	client     HTTPClient
+}
+
+// NewMiddlewareClient returns a HTTPClient that makes requests using the
+// given client after running them through the given middleware. The returned
+// client can be passed to NewClient or NewCachedClient.
+func NewMiddlewareClient(
+	client HTTPClient,
+	middleware ...Middleware) HTTPRequestClient {
+
+	return &middlewareHTTPClient{
+		middleware: append([]Middleware(nil), middleware...),
+		client:     client,
+	}
+}
+
+// Use adds the given middleware to the requests made by the client's
+// Provider, after any middleware it already runs. Like middleware given to
+// NewMiddlewareClient, it runs for each attempt, just before the request is
+// sent by the client's HTTPClient, so it sees requests for every read and
+// write made by the client.
+//
+// Use must not be called while the client is making requests.
+// ErrMiddlewareUnsupported is returned if the Provider was not created by
+// this package.
+func (c *Client) Use(middleware ...Middleware) error {
+	provider := c.Provider
+	if cached, ok := provider.(*cachedContentProvider); ok {
+		provider = cached.delegate
+	}
+
+	var apiClient httpAPIClient
+	switch p := provider.(type) {
+	case *xmlContentProvider:
+		apiClient = p.client
+	case *jsonContentProvider:
+		apiClient = p.client
+	}
+	counting, ok := apiClient.(*countingHTTPApiClient)
+	if !ok {
+		return ErrMiddlewareUnsupported
+	}
+	counting.client = addMiddleware(counting.client, middleware)
+	return nil
+}
+
+// addMiddleware runs the given middleware just before requests are sent by
+// the HTTPClient wrapped by the client, keeping any rate limiter and metrics
+// wrapping it in place.
+func addMiddleware(client HTTPClient, middleware []Middleware) HTTPClient {
+	switch c := client.(type) {
+	case *rateLimitedHTTPClient:
+		c.client = addMiddleware(c.client, middleware)
+		return c
+	case *measuredHTTPClient:
+		c.client = addMiddleware(c.client, middleware)
+		return c
+	case *middlewareHTTPClient:
+		c.middleware = append(c.middleware, middleware...)
+		return c
+	}
+	return NewMiddlewareClient(client, middleware...)
+}
+
+//
+// HTTPClient
+//
+
+// Get runs a GET request to the given URL through the client's middleware.
+func (c *middlewareHTTPClient) Get(url string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(request)
+}
+
+// Do runs the given request through the client's middleware.
+func (c *middlewareHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	var response *http.Response
+	var err error
+
+	ran := 0
+	for _, m := range c.middleware {
+		if m.BeforeRequest != nil {
+			if err = m.BeforeRequest(request); err != nil {
+				break
+			}
+		}
+		ran++
+	}
+
+	if err == nil {
+		response, err = do(c.client, request)
+	}
+
+	for i := ran - 1; i >= 0; i-- {
+		m := c.middleware[i]
+		if err != nil {
+			if m.OnError != nil {
+				response, err = m.OnError(request, err)
+			}
+		} else if m.AfterResponse != nil {
+			response, err = m.AfterResponse(request, response)
+		}
+	}
+	return response, err
+}
@@ -0,0 +1,283 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+//
+// Test middlewareHTTPClient
+//
+
+func TestMiddlewareClientOrder(t *testing.T) {
+	calls := []string{}
+	record := func(name string) Middleware {
+		return Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				calls = append(calls, "before "+name)
+				return nil
+			},
+			AfterResponse: func(
+				request *http.Request,
+				response *http.Response) (*http.Response, error) {
+
+				calls = append(calls, "after "+name)
+				return response, nil
+			},
+		}
+	}
+	client := NewMiddlewareClient(
+		&mockHTTPClient{Response: &http.Response{}},
+		record("first"),
+		record("second"))
+
+	if _, err := client.Get("http://example.com"); err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	expected := []string{
+		"before first",
+		"before second",
+		"after second",
+		"after first",
+	}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("Unexpected middleware calls\n\texpected: %+v\n\t"+
+			"actual: %+v",
+			expected,
+			calls)
+	}
+}
+
+func TestMiddlewareClientModifiesRequest(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	client := NewMiddlewareClient(
+		httpClient,
+		Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				request.Header.Set("X-Request-ID", "1234")
+				return nil
+			},
+		})
+
+	client.Get("http://example.com")
+
+	if httpClient.LastRequest == nil {
+		t.Fatalf("request not sent to client")
+	}
+	assertStringEquals(
+		t,
+		"1234",
+		httpClient.LastRequest.Header.Get("X-Request-ID"))
+}
+
+func TestMiddlewareClientGetOnlyClient(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	client := NewMiddlewareClient(
+		&mockGetHTTPClient{httpClient},
+		Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				request.URL.Path = "/modified"
+				return nil
+			},
+		})
+
+	client.Get("http://example.com/original")
+
+	assertStringEquals(t, "http://example.com/modified", httpClient.LastURL)
+}
+
+func TestMiddlewareClientGetOnlyClientHeaders(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	var errs []error
+	client := NewMiddlewareClient(
+		&mockGetHTTPClient{httpClient},
+		Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				request.Header.Set("X-Request-ID", "1234")
+				return nil
+			},
+			OnError: func(
+				request *http.Request,
+				err error) (*http.Response, error) {
+
+				errs = append(errs, err)
+				return nil, err
+			},
+		})
+
+	_, err := client.Get("http://example.com")
+
+	if err != ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for request with headers: %v", err)
+	}
+	assertStringEquals(t, "", httpClient.LastURL)
+	assertIntEquals(t, 1, len(errs))
+}
+
+func TestMiddlewareClientBeforeRequestError(t *testing.T) {
+	expected := errors.New("error")
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	var errs []error
+	client := NewMiddlewareClient(
+		httpClient,
+		Middleware{
+			OnError: func(
+				request *http.Request,
+				err error) (*http.Response, error) {
+
+				errs = append(errs, err)
+				return nil, err
+			},
+		},
+		Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				return expected
+			},
+			OnError: func(
+				request *http.Request,
+				err error) (*http.Response, error) {
+
+				t.Fatalf("OnError called for middleware that failed")
+				return nil, err
+			},
+		})
+
+	_, err := client.Get("http://example.com")
+	if err != expected {
+		t.Fatalf("Unexpected error\n\texpected: %s\n\tactual: %v",
+			expected,
+			err)
+	}
+
+	assertIntEquals(t, 0, httpClient.RequestCount)
+	if len(errs) != 1 || errs[0] != expected {
+		t.Fatalf("Unexpected errors passed to OnError: %+v", errs)
+	}
+}
+
+func TestMiddlewareClientRecoversFromError(t *testing.T) {
+	expected := &http.Response{}
+	client := NewMiddlewareClient(
+		&mockHTTPClient{Error: errors.New("error"), ErrorCount: 1},
+		Middleware{
+			OnError: func(
+				request *http.Request,
+				err error) (*http.Response, error) {
+
+				return expected, nil
+			},
+		})
+
+	response, err := client.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Error not recovered: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("received unexpected response from client")
+	}
+}
+
+func TestMiddlewareClientFaultInjection(t *testing.T) {
+	attempts := 0
+	client := NewClient(NewMiddlewareClient(
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			return mockResponse(leagueXMLContent), nil
+		}),
+		Middleware{
+			AfterResponse: func(
+				request *http.Request,
+				response *http.Response) (*http.Response, error) {
+
+				attempts++
+				if attempts == 1 {
+					response.Body.Close()
+					return &http.Response{
+						StatusCode: StatusYahooThrottled,
+						Header:     http.Header{},
+						Body:       mockResponse("").Body,
+					}, nil
+				}
+				return response, nil
+			},
+		}))
+	apiClient := client.Provider.(*xmlContentProvider).client.(*countingHTTPApiClient)
+	apiClient.retryPolicy = NewBackoffRetryPolicy()
+	apiClient.sleep = noSleep
+
+	league, err := client.GetLeagueMetadata("223.l.431")
+	if err != nil {
+		t.Fatalf("Injected fault not retried: %s", err)
+	}
+
+	assertStringEquals(t, "223.l.431", league.LeagueKey)
+	assertIntEquals(t, 2, client.RequestCount())
+}
+
+//
+// Test Client.Use
+//
+
+func TestClientUse(t *testing.T) {
+	calls := []string{}
+	record := func(name string) Middleware {
+		return Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				calls = append(calls, name)
+				request.Header.Set("X-"+name, "value")
+				return nil
+			},
+		}
+	}
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueXMLContent)}
+	client := New(
+		httpClient,
+		WithCache(mockCache()),
+		WithMetrics(NewRequestMetrics()),
+		WithMiddleware(record("first")))
+
+	if err := client.Use(record("second")); err != nil {
+		t.Fatalf("error adding middleware: %s", err)
+	}
+	if _, err := client.GetLeagueMetadata("223.l.431"); err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+
+	expected := []string{"first", "second"}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("Unexpected middleware calls\n\texpected: %+v\n\t"+
+			"actual: %+v",
+			expected,
+			calls)
+	}
+	assertStringEquals(t, "value", httpClient.LastRequest.Header.Get("X-second"))
+}
+
+func TestClientUseWithoutMiddleware(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(leagueXMLContent)}
+	client := NewClient(httpClient)
+
+	err := client.Use(Middleware{
+		BeforeRequest: func(request *http.Request) error {
+			request.Header.Set("X-Test", "value")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding middleware: %s", err)
+	}
+	if _, err := client.GetLeagueMetadata("223.l.431"); err != nil {
+		t.Fatalf("error retrieving league: %s", err)
+	}
+	assertStringEquals(t, "value", httpClient.LastRequest.Header.Get("X-Test"))
+}
+
+func TestClientUseUnsupportedProvider(t *testing.T) {
+	client := mockClient(&FantasyContent{}, nil)
+	if err := client.Use(Middleware{}); err != ErrMiddlewareUnsupported {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
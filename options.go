@@ -0,0 +1,180 @@
+package goff
+
+// Option configures a Client created by New.
+type Option func(o *options)
+
+// options collects the configuration of a Client created by New.
+type options struct {
+	cache        Cache
+	staleOnError bool
+	retryPolicy  *RetryPolicy
+	limiter      *RateLimiter
+	metrics      Metrics
+	middleware   []Middleware
+	tracer       Tracer
+	logger       Logger
+	format       Format
+	baseURL      string
+}
+
+// New creates a Client that communicates with the Yahoo fantasy sports API
+// using the given HTTPClient, configured by the given options. See the package
+// level documentation for one way to create a http.Client that can
+// authenticate with Yahoo's APIs.
+//
+// The options are applied in order, so later options replace earlier ones,
+// except for WithMiddleware which adds to any previous middleware. Regardless
+// of the order of the options, each request to the API passes through the
+// following, as configured:
+//
+//    1. The cache, returning cached content without making a request
+//    2. The retry policy, retrying the steps below when a request fails
+//    3. The rate limiter, waiting or failing before each attempt
+//    4. The metrics, measuring each attempt
+//    5. The middleware, in the order given
+//    6. The HTTPClient
+//
+// For example, the following creates a client that caches content, limits
+// requests to 20 per second, and requests JSON:
+//
+//    client := goff.New(
+//        httpClient,
+//        goff.WithCache(cache),
+//        goff.WithRateLimiter(goff.NewRateLimiter(goff.RateLimit{PerSecond: 20})),
+//        goff.WithFormat(goff.FormatJSON))
+func New(httpClient HTTPClient, opts ...Option) *Client {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	client := httpClient
+	if len(o.middleware) > 0 {
+		client = NewMiddlewareClient(client, o.middleware...)
+	}
+	if o.metrics != nil {
+		client = NewMeasuredClient(client, o.metrics)
+	}
+	if o.limiter != nil {
+		client = o.limiter.Client(client)
+	}
+
+	apiClient := &countingHTTPApiClient{
+		client:      client,
+		retryPolicy: o.retryPolicy,
+	}
+	var provider ContentProvider = &xmlContentProvider{client: apiClient}
+	if o.format == FormatJSON {
+		provider = &jsonContentProvider{client: apiClient}
+	}
+	if o.cache != nil {
+		provider = &cachedContentProvider{
+			delegate:     provider,
+			cache:        o.cache,
+			staleOnError: o.staleOnError,
+		}
+	}
+
+	return &Client{
+		Provider: provider,
+		Tracer:   o.tracer,
+		Logger:   o.logger,
+		BaseURL:  o.baseURL,
+	}
+}
+
+// WithCache checks and updates the given Cache when retrieving content.
+//
+// See NewLRUCache
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithUserCache checks and updates the content cached for the given user in
+// the UserCache, keeping it separate from the content cached for other users.
+func WithUserCache(userID string, cache UserCache) Option {
+	return WithCache(cache.ForUser(userID))
+}
+
+// WithStaleOnError returns content cached during the previous cache period
+// when a request fails for any reason other than ErrAccessDenied. The content
+// is returned along with a StaleContentError, so callers can tell it is out
+// of date:
+//
+//    team, err := client.GetTeam(teamKey)
+//    var stale *goff.StaleContentError
+//    if err != nil && !errors.As(err, &stale) {
+//        return err
+//    }
+//
+// Only has an effect when the client is configured with a StaleCache, such as
+// LRUCache.
+func WithStaleOnError() Option {
+	return func(o *options) {
+		o.staleOnError = true
+	}
+}
+
+// WithRetryPolicy retries failed requests using the given policy instead of
+// DefaultRetryPolicy.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithRateLimiter limits the requests made to the API using the given
+// RateLimiter, which may be shared with other clients.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithMetrics records each request made to the API in the given Metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithMiddleware passes each request made to the API through the given
+// middleware, after any middleware added by previous options.
+//
+// See NewMiddlewareClient
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithTracer sets the Tracer of the Client.
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithLogger sets the Logger of the Client.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithFormat requests content in the given format. FormatXML is used by
+// default.
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithBaseURL sets the BaseURL of the Client.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
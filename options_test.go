@@ -0,0 +1,210 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test New
+//
+
+func TestNewDefaults(t *testing.T) {
+	client := New(&mockHTTPClient{})
+
+	provider, ok := client.Provider.(*xmlContentProvider)
+	if !ok {
+		t.Fatalf("unexpected provider: %T", client.Provider)
+	}
+	apiClient := provider.client.(*countingHTTPApiClient)
+	if apiClient.retryPolicy != nil {
+		t.Fatalf("retry policy set without an option")
+	}
+	if client.Tracer != nil || client.Logger != nil || client.BaseURL != "" {
+		t.Fatalf("unexpected client configuration: %+v", client)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024))
+	policy := &RetryPolicy{MaxAttempts: 1}
+	tracer := &mockTracer{}
+	logger := &mockLogger{}
+	client := New(
+		&mockHTTPClient{},
+		WithCache(cache),
+		WithRetryPolicy(policy),
+		WithTracer(tracer),
+		WithLogger(logger),
+		WithFormat(FormatJSON),
+		WithBaseURL("http://localhost/fantasy/v2"))
+
+	cached, ok := client.Provider.(*cachedContentProvider)
+	if !ok {
+		t.Fatalf("unexpected provider: %T", client.Provider)
+	}
+	if cached.cache != cache {
+		t.Fatalf("cache not used by provider")
+	}
+	provider, ok := cached.delegate.(*jsonContentProvider)
+	if !ok {
+		t.Fatalf("unexpected cache delegate: %T", cached.delegate)
+	}
+	if provider.client.(*countingHTTPApiClient).retryPolicy != policy {
+		t.Fatalf("retry policy not used by provider")
+	}
+	if client.Tracer != tracer || client.Logger != logger {
+		t.Fatalf("tracer or logger not set: %+v", client)
+	}
+	assertStringEquals(t, "http://localhost/fantasy/v2", client.BaseURL)
+}
+
+func TestNewWithUserCache(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	url := YahooBaseURL + "/league/223.l.431"
+	first := New(
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)},
+		WithUserCache("user1", cache))
+	secondHTTPClient := &mockHTTPClient{Response: mockResponse(leagueXMLContent)}
+	second := New(secondHTTPClient, WithUserCache("user2", cache))
+
+	first.GetFantasyContent(url)
+	second.GetFantasyContent(url)
+
+	assertIntEquals(t, 1, secondHTTPClient.RequestCount)
+}
+
+func TestNewWithStaleOnError(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	url := YahooBaseURL + "/league/223.l.431"
+	expected := createLeagueList(League{LeagueKey: "223.l.431"})
+	cache.Set(url, time.Now().Add(-time.Hour), expected)
+	logger := &mockLogger{}
+	client := New(
+		&mockHTTPClient{Error: errors.New("error"), ErrorCount: 1},
+		WithCache(cache),
+		WithStaleOnError(),
+		WithRetryPolicy(&RetryPolicy{}),
+		WithLogger(logger))
+
+	_, err := client.GetFantasyContent(url)
+
+	var stale *StaleContentError
+	if !errors.As(err, &stale) {
+		t.Fatalf("stale content not returned: %v", err)
+	}
+	if stale.Content != expected {
+		t.Fatalf("unexpected stale content: %+v", stale.Content)
+	}
+	if len(logger.errors) != 1 || logger.errors[0]["cache"] != "stale" {
+		t.Fatalf("stale content not logged: %+v", logger.errors)
+	}
+}
+
+func TestNewWithStaleOnErrorGetTeam(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	client := New(
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			return nil, errors.New("error")
+		}),
+		WithCache(cache),
+		WithStaleOnError(),
+		WithRetryPolicy(&RetryPolicy{}))
+	url := client.URL().Team("223.l.431.t.1").
+		Out("stats", "metadata", "players", "standings", "roster").
+		String()
+	cache.Set(
+		url,
+		time.Now().Add(-time.Hour),
+		&FantasyContent{Team: Team{TeamKey: "223.l.431.t.1", TeamID: 1}})
+
+	team, err := client.GetTeam("223.l.431.t.1")
+
+	var stale *StaleContentError
+	if !errors.As(err, &stale) {
+		t.Fatalf("stale content not returned: %v", err)
+	}
+	if team == nil {
+		t.Fatal("stale team not returned")
+	}
+	assertStringEquals(t, "223.l.431.t.1", team.TeamKey)
+
+	_, err = client.GetTeam("223.l.431.t.2")
+	if err == nil || errors.As(err, &stale) {
+		t.Fatalf("unexpected error for team without stale content: %v", err)
+	}
+}
+
+func TestNewRequestOrder(t *testing.T) {
+	calls := []string{}
+	record := func(name string) Middleware {
+		return Middleware{
+			BeforeRequest: func(request *http.Request) error {
+				calls = append(calls, name)
+				return nil
+			},
+		}
+	}
+	// Fails the first attempt, which is retried
+	attempts := 0
+	failFirst := Middleware{
+		AfterResponse: func(
+			request *http.Request,
+			response *http.Response) (*http.Response, error) {
+
+			attempts++
+			if attempts == 1 {
+				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Body:       mockResponse("").Body,
+				}, nil
+			}
+			return response, nil
+		},
+	}
+	limiter := NewRateLimiter(RateLimit{PerHour: 10})
+	metrics := NewRequestMetrics()
+	client := New(
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			calls = append(calls, "http")
+			response := mockResponse(leagueXMLContent)
+			response.StatusCode = http.StatusOK
+			return response, nil
+		}),
+		WithMiddleware(record("first")),
+		WithRateLimiter(limiter),
+		WithMetrics(metrics),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2}),
+		WithMiddleware(record("second"), failFirst))
+
+	_, err := client.GetFantasyContent(YahooBaseURL + "/league/223.l.431")
+	if err != nil {
+		t.Fatalf("error retrieving content: %s", err)
+	}
+
+	expected := []string{"first", "second", "http", "first", "second", "http"}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("Unexpected calls\n\texpected: %+v\n\tactual: %+v",
+			expected,
+			calls)
+	}
+	league := metrics.Resources()["league"]
+	assertIntEquals(t, 2, int(league.Requests))
+	assertIntEquals(t, 1, int(league.StatusCodes[http.StatusInternalServerError]))
+	assertIntEquals(t, 8, limiter.Remaining().PerHour)
+}
+
+func TestNewCachedClientWrapsNew(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024))
+	client := NewCachedFormatClient(cache, &mockHTTPClient{}, FormatJSON)
+
+	cached := client.Provider.(*cachedContentProvider)
+	if _, ok := cached.delegate.(*jsonContentProvider); !ok {
+		t.Fatalf("unexpected cache delegate: %T", cached.delegate)
+	}
+}
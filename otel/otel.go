@@ -0,0 +1,90 @@
+// Package otel adapts OpenTelemetry tracers for use by goff clients.
+//
+// Set the Tracer of a goff.Client to record a span for each of its requests:
+//
+//    client.Tracer = otel.NewTracer(tracerProvider.Tracer("goff"))
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/e0/goff"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer implements goff.Tracer using an OpenTelemetry trace.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+	parent func() context.Context
+}
+
+// span implements goff.Span using an OpenTelemetry trace.Span.
+type span struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+// NewTracer creates a Tracer that starts spans using the given tracer. Root
+// spans are started without a parent.
+func NewTracer(tracer trace.Tracer) *Tracer {
+	return NewContextTracer(tracer, context.Background)
+}
+
+// NewContextTracer creates a Tracer that starts spans using the given tracer.
+// Root spans are started as children of the span in the context returned by
+// the given function, if any.
+func NewContextTracer(
+	tracer trace.Tracer,
+	parent func() context.Context) *Tracer {
+
+	return &Tracer{tracer: tracer, parent: parent}
+}
+
+// StartSpan starts a span with the given name as a child of the parent span,
+// or as a root span when parent is nil or was not started by a Tracer.
+func (t *Tracer) StartSpan(name string, parent goff.Span) goff.Span {
+	ctx := t.parent()
+	if parent, ok := parent.(*span); ok {
+		ctx = parent.ctx
+	}
+	ctx, s := t.tracer.Start(ctx, name)
+	return &span{ctx: ctx, span: s}
+}
+
+// SetAttribute records the attribute on the span, converting values other
+// than strings, integers, floats, and booleans to strings.
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+// RecordError records the error on the span and marks the span as failed.
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End completes the span.
+func (s *span) End() {
+	s.span.End()
+}
+
+// keyValue returns the OpenTelemetry attribute for the key and value.
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,146 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//
+// Test Tracer
+//
+
+func TestTracerStartSpanParent(t *testing.T) {
+	tracer := &mockTracer{}
+	adapter := NewTracer(tracer)
+
+	root := adapter.StartSpan("root", nil)
+	adapter.StartSpan("child", root)
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Unexpected amount of spans: %d", len(tracer.spans))
+	}
+	if tracer.spans[0].parent != nil {
+		t.Fatalf("Root span started with a parent")
+	}
+	if tracer.spans[1].parent != tracer.spans[0] {
+		t.Fatalf("Child span not started with its parent")
+	}
+}
+
+func TestContextTracerStartSpan(t *testing.T) {
+	tracer := &mockTracer{}
+	parent := &mockSpan{name: "request"}
+	adapter := NewContextTracer(tracer, func() context.Context {
+		return trace.ContextWithSpan(context.Background(), parent)
+	})
+
+	adapter.StartSpan("root", nil)
+
+	if tracer.spans[0].parent != parent {
+		t.Fatalf("Root span not started as a child of the context's span")
+	}
+}
+
+func TestSpanAttributes(t *testing.T) {
+	tracer := &mockTracer{}
+	span := NewTracer(tracer).StartSpan("span", nil)
+
+	span.SetAttribute("string", "value")
+	span.SetAttribute("int", 10)
+	span.SetAttribute("bool", true)
+	span.SetAttribute("other", []string{"a"})
+
+	expected := map[attribute.Key]interface{}{
+		"string": "value",
+		"int":    int64(10),
+		"bool":   true,
+		"other":  "[a]",
+	}
+	actual := tracer.spans[0].attributes
+	for key, value := range expected {
+		if actual[key] != value {
+			t.Fatalf("Unexpected attribute %s\n\texpected: %v\n\tactual: %v",
+				key,
+				value,
+				actual[key])
+		}
+	}
+}
+
+func TestSpanRecordError(t *testing.T) {
+	tracer := &mockTracer{}
+	span := NewTracer(tracer).StartSpan("span", nil)
+	expected := errors.New("error")
+
+	span.RecordError(expected)
+	span.End()
+
+	actual := tracer.spans[0]
+	if actual.err != expected {
+		t.Fatalf("Unexpected error: %v", actual.err)
+	}
+	if actual.status != codes.Error {
+		t.Fatalf("Span not marked as failed")
+	}
+	if !actual.ended {
+		t.Fatalf("Span not ended")
+	}
+}
+
+//
+// Mocks
+//
+
+type mockTracer struct {
+	trace.Tracer
+	spans []*mockSpan
+}
+
+type mockSpan struct {
+	trace.Span
+	name       string
+	parent     trace.Span
+	attributes map[attribute.Key]interface{}
+	err        error
+	status     codes.Code
+	ended      bool
+}
+
+func (m *mockTracer) Start(
+	ctx context.Context,
+	name string,
+	opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+
+	span := &mockSpan{
+		name:       name,
+		attributes: make(map[attribute.Key]interface{}),
+	}
+	if parent, ok := trace.SpanFromContext(ctx).(*mockSpan); ok {
+		span.parent = parent
+	}
+	m.spans = append(m.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *mockSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attribute := range kv {
+		s.attributes[attribute.Key] = attribute.Value.AsInterface()
+	}
+}
+
+func (s *mockSpan) RecordError(err error, options ...trace.EventOption) {
+	s.err = err
+}
+
+func (s *mockSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *mockSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
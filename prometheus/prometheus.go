@@ -0,0 +1,332 @@
+// Package prometheus exports the usage of the Yahoo fantasy sports API by goff
+// clients as Prometheus metrics.
+//
+// An Exporter records requests, retries, cache usage, and rate limiter waits
+// and can serve them to Prometheus from a /metrics endpoint:
+//
+//    exporter := prometheus.NewExporter("goff")
+//    limiter := goff.NewRateLimiter(goff.RateLimit{PerHour: 20000})
+//    cache := goff.NewByteSizedLRUCache(clientID, time.Hour,
+//        lru.NewLRUCache(64*1024*1024))
+//    policy := goff.NewBackoffRetryPolicy()
+//    policy.OnRetry = exporter.ObserveRetry
+//    client := goff.New(httpClient,
+//        goff.WithCache(cache),
+//        goff.WithRateLimiter(limiter),
+//        goff.WithMetrics(exporter),
+//        goff.WithRetryPolicy(policy))
+//
+//    exporter.WatchCache("default", cache)
+//    exporter.WatchRateLimiter("default", limiter)
+//    exporter.WatchClient("default", client)
+//
+//    http.Handle("/metrics", exporter.Handler())
+//
+// The Exporter is also a prometheus.Collector, so it can be registered with
+// an existing prometheus.Registry instead of using its own handler.
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/e0/goff"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Exporter implements goff.Metrics and prometheus.Collector. It records every
+// request reported to it and collects the statistics of the caches, rate
+// limiters, and clients it watches each time it is scraped. Exporter is safe
+// for concurrent use.
+type Exporter struct {
+	requests *prom.CounterVec
+	latency  *prom.HistogramVec
+	retries  *prom.CounterVec
+
+	cacheRequests   *prom.Desc
+	cacheEvictions  *prom.Desc
+	cacheEntries    *prom.Desc
+	cacheSize       *prom.Desc
+	limiterWaits    *prom.Desc
+	limiterWaitTime *prom.Desc
+	limiterRejected *prom.Desc
+	limiterBudget   *prom.Desc
+	clientRequests  *prom.Desc
+
+	mutex    sync.Mutex
+	caches   map[string]goff.CacheInspector
+	limiters map[string]*goff.RateLimiter
+	clients  map[string]*goff.Client
+	registry *prom.Registry
+}
+
+// NewExporter creates an Exporter whose metric names all start with the given
+// namespace, e.g. "goff_requests_total" for the namespace "goff".
+func NewExporter(namespace string) *Exporter {
+	desc := func(name string, help string, labels ...string) *prom.Desc {
+		return prom.NewDesc(
+			prom.BuildFQName(namespace, "", name),
+			help,
+			labels,
+			nil)
+	}
+
+	e := &Exporter{
+		requests: prom.NewCounterVec(
+			prom.CounterOpts{
+				Namespace: namespace,
+				Name:      "requests_total",
+				Help: "Requests made to the fantasy sports API by resource, " +
+					"response status code, and whether an error was returned.",
+			},
+			[]string{"resource", "status", "error"}),
+		latency: prom.NewHistogramVec(
+			prom.HistogramOpts{
+				Namespace: namespace,
+				Name:      "request_duration_seconds",
+				Help:      "Time taken by requests to the fantasy sports API.",
+				Buckets:   latencyBuckets(goff.DefaultLatencyBuckets),
+			},
+			[]string{"resource"}),
+		retries: prom.NewCounterVec(
+			prom.CounterOpts{
+				Namespace: namespace,
+				Name:      "retries_total",
+				Help: "Failed requests to the fantasy sports API that were " +
+					"retried by resource and response status code.",
+			},
+			[]string{"resource", "status"}),
+
+		cacheRequests: desc(
+			"cache_requests_total",
+			"Requests for cached content by resource and result.",
+			"cache", "resource", "result"),
+		cacheEvictions: desc(
+			"cache_evictions_total",
+			"Cached content evicted to make room for new content.",
+			"cache"),
+		cacheEntries: desc(
+			"cache_entries",
+			"Amount of content currently cached.",
+			"cache"),
+		cacheSize: desc(
+			"cache_size",
+			"Size of the content currently cached, in bytes for caches "+
+				"created with NewByteSizedLRUCache and entries otherwise.",
+			"cache"),
+		limiterWaits: desc(
+			"rate_limiter_waits_total",
+			"Requests delayed to stay within the rate limit.",
+			"limiter"),
+		limiterWaitTime: desc(
+			"rate_limiter_wait_seconds_total",
+			"Time spent waiting to stay within the rate limit.",
+			"limiter"),
+		limiterRejected: desc(
+			"rate_limiter_rejections_total",
+			"Requests rejected because they would exceed the rate limit.",
+			"limiter"),
+		limiterBudget: desc(
+			"rate_limiter_remaining",
+			"Requests that can currently be made within the rate limit.",
+			"limiter", "window"),
+		clientRequests: desc(
+			"client_requests_total",
+			"Requests made to the fantasy sports API by a client, including "+
+				"retries.",
+			"client"),
+
+		caches:   make(map[string]goff.CacheInspector),
+		limiters: make(map[string]*goff.RateLimiter),
+		clients:  make(map[string]*goff.Client),
+	}
+
+	e.registry = prom.NewRegistry()
+	e.registry.MustRegister(e)
+	return e
+}
+
+// ObserveRequest records a single request for the given type of resource.
+func (e *Exporter) ObserveRequest(
+	resource string,
+	statusCode int,
+	duration time.Duration,
+	err error) {
+
+	e.requests.WithLabelValues(
+		resource,
+		status(statusCode),
+		strconv.FormatBool(err != nil)).Inc()
+	e.latency.WithLabelValues(resource).Observe(duration.Seconds())
+}
+
+// ObserveRetry records a failed request for the URL that is about to be
+// retried. It can be used as the OnRetry function of a goff.RetryPolicy.
+func (e *Exporter) ObserveRetry(url string, attempt goff.RetryAttempt) {
+	e.retries.WithLabelValues(
+		goff.ResourceType(url),
+		status(attempt.StatusCode)).Inc()
+}
+
+// WatchCache exports the usage of the given cache with the given name. Any
+// cache previously watched with the same name is replaced.
+func (e *Exporter) WatchCache(name string, cache goff.CacheInspector) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.caches[name] = cache
+}
+
+// WatchRateLimiter exports the waits, rejections, and remaining budget of the
+// given limiter with the given name. Any limiter previously watched with the
+// same name is replaced.
+func (e *Exporter) WatchRateLimiter(name string, limiter *goff.RateLimiter) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.limiters[name] = limiter
+}
+
+// WatchClient exports the RequestCount of the given client with the given
+// name. Any client previously watched with the same name is replaced.
+func (e *Exporter) WatchClient(name string, client *goff.Client) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.clients[name] = client
+}
+
+// Handler returns a http.Handler serving the exporter's metrics in the
+// Prometheus text format, typically at "/metrics".
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+//
+// prometheus.Collector
+//
+
+// Describe sends the descriptors of every metric exported.
+func (e *Exporter) Describe(ch chan<- *prom.Desc) {
+	e.requests.Describe(ch)
+	e.latency.Describe(ch)
+	e.retries.Describe(ch)
+	ch <- e.cacheRequests
+	ch <- e.cacheEvictions
+	ch <- e.cacheEntries
+	ch <- e.cacheSize
+	ch <- e.limiterWaits
+	ch <- e.limiterWaitTime
+	ch <- e.limiterRejected
+	ch <- e.limiterBudget
+	ch <- e.clientRequests
+}
+
+// Collect sends the requests recorded so far and the current statistics of
+// every watched cache, rate limiter, and client.
+func (e *Exporter) Collect(ch chan<- prom.Metric) {
+	e.requests.Collect(ch)
+	e.latency.Collect(ch)
+	e.retries.Collect(ch)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for name, cache := range e.caches {
+		e.collectCache(ch, name, cache.Stats())
+	}
+	for name, limiter := range e.limiters {
+		e.collectRateLimiter(ch, name, limiter)
+	}
+	for name, client := range e.clients {
+		ch <- prom.MustNewConstMetric(
+			e.clientRequests,
+			prom.CounterValue,
+			float64(client.RequestCount()),
+			name)
+	}
+}
+
+// collectCache sends the metrics for the statistics of a single cache
+func (e *Exporter) collectCache(
+	ch chan<- prom.Metric,
+	name string,
+	stats goff.CacheStats) {
+
+	for resource, usage := range stats.Resources {
+		results := map[string]int64{
+			"hit":   usage.Hits,
+			"miss":  usage.Misses,
+			"stale": usage.Stale,
+		}
+		for result, count := range results {
+			ch <- prom.MustNewConstMetric(
+				e.cacheRequests,
+				prom.CounterValue,
+				float64(count),
+				name,
+				resource,
+				result)
+		}
+	}
+
+	ch <- prom.MustNewConstMetric(
+		e.cacheEvictions, prom.CounterValue, float64(stats.Evictions), name)
+	ch <- prom.MustNewConstMetric(
+		e.cacheEntries, prom.GaugeValue, float64(stats.Entries), name)
+	ch <- prom.MustNewConstMetric(
+		e.cacheSize, prom.GaugeValue, float64(stats.Size), name)
+}
+
+// collectRateLimiter sends the metrics for a single rate limiter. Windows
+// without a configured limit are not reported.
+func (e *Exporter) collectRateLimiter(
+	ch chan<- prom.Metric,
+	name string,
+	limiter *goff.RateLimiter) {
+
+	stats := limiter.Stats()
+	ch <- prom.MustNewConstMetric(
+		e.limiterWaits, prom.CounterValue, float64(stats.Waits), name)
+	ch <- prom.MustNewConstMetric(
+		e.limiterWaitTime, prom.CounterValue, stats.WaitTime.Seconds(), name)
+	ch <- prom.MustNewConstMetric(
+		e.limiterRejected, prom.CounterValue, float64(stats.Rejections), name)
+
+	budget := limiter.Remaining()
+	windows := map[string]int{
+		"second": budget.PerSecond,
+		"hour":   budget.PerHour,
+	}
+	for window, remaining := range windows {
+		if remaining < 0 {
+			continue
+		}
+		ch <- prom.MustNewConstMetric(
+			e.limiterBudget,
+			prom.GaugeValue,
+			float64(remaining),
+			name,
+			window)
+	}
+}
+
+// status returns the label value for a response status code, or "error" when
+// no response was received.
+func status(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode)
+}
+
+// latencyBuckets converts the durations to sorted histogram bounds in seconds.
+func latencyBuckets(durations []time.Duration) []float64 {
+	buckets := make([]float64, len(durations))
+	for i, duration := range durations {
+		buckets[i] = duration.Seconds()
+	}
+	sort.Float64s(buckets)
+	return buckets
+}
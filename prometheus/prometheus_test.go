@@ -0,0 +1,151 @@
+package prometheus
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e0/goff"
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test Exporter
+//
+
+func TestExporterObserveRequest(t *testing.T) {
+	exporter := NewExporter("goff")
+	exporter.ObserveRequest("league", 200, 30*time.Millisecond, nil)
+	exporter.ObserveRequest("league", 200, 2*time.Second, nil)
+	exporter.ObserveRequest("team", 0, time.Second, errors.New("error"))
+	exporter.ObserveRequest("team", 200, time.Second, errors.New("error"))
+
+	metrics := scrape(t, exporter)
+	assertMetric(t, metrics, `goff_requests_total{error="false",resource="league",status="200"} 2`)
+	assertMetric(t, metrics, `goff_requests_total{error="true",resource="team",status="error"} 1`)
+	assertMetric(t, metrics, `goff_requests_total{error="true",resource="team",status="200"} 1`)
+	assertMetric(t, metrics, `goff_request_duration_seconds_bucket{resource="league",le="0.05"} 1`)
+	assertMetric(t, metrics, `goff_request_duration_seconds_bucket{resource="league",le="2.5"} 2`)
+	assertMetric(t, metrics, `goff_request_duration_seconds_count{resource="league"} 2`)
+}
+
+func TestExporterMeasuredClient(t *testing.T) {
+	exporter := NewExporter("goff")
+	client := goff.NewClient(goff.NewMeasuredClient(
+		&mockHTTPClient{StatusCode: http.StatusOK, Body: leagueXMLContent},
+		exporter))
+	exporter.WatchClient("test", client)
+
+	client.GetFantasyContent(goff.YahooBaseURL + "/league/223.l.431")
+
+	metrics := scrape(t, exporter)
+	assertMetric(t, metrics, `goff_requests_total{error="false",resource="league",status="200"} 1`)
+	assertMetric(t, metrics, `goff_client_requests_total{client="test"} 1`)
+}
+
+func TestExporterObserveRetry(t *testing.T) {
+	exporter := NewExporter("goff")
+	url := goff.YahooBaseURL + "/users;use_login=1/games/leagues"
+	exporter.ObserveRetry(url, goff.RetryAttempt{StatusCode: 503})
+	exporter.ObserveRetry(url, goff.RetryAttempt{Err: errors.New("error")})
+	exporter.ObserveRetry(url, goff.RetryAttempt{StatusCode: 503})
+
+	metrics := scrape(t, exporter)
+	assertMetric(t, metrics, `goff_retries_total{resource="users",status="503"} 2`)
+	assertMetric(t, metrics, `goff_retries_total{resource="users",status="error"} 1`)
+}
+
+func TestExporterWatchCache(t *testing.T) {
+	exporter := NewExporter("goff")
+	cache := goff.NewLRUCache(
+		"clientID",
+		time.Hour,
+		lru.NewLRUCache(1024*1024))
+	exporter.WatchCache("test", cache)
+
+	url := goff.YahooBaseURL + "/league/223.l.431"
+	now := time.Now()
+	cache.Get(url, now)
+	cache.Set(url, now, &goff.FantasyContent{})
+	cache.Get(url, now)
+	cache.Get(url, now)
+
+	metrics := scrape(t, exporter)
+	assertMetric(t, metrics, `goff_cache_requests_total{cache="test",resource="league",result="hit"} 2`)
+	assertMetric(t, metrics, `goff_cache_requests_total{cache="test",resource="league",result="miss"} 1`)
+	assertMetric(t, metrics, `goff_cache_requests_total{cache="test",resource="league",result="stale"} 0`)
+	assertMetric(t, metrics, `goff_cache_entries{cache="test"} 1`)
+	assertMetric(t, metrics, `goff_cache_evictions_total{cache="test"} 0`)
+}
+
+func TestExporterWatchRateLimiter(t *testing.T) {
+	exporter := NewExporter("goff")
+	limiter := goff.NewRateLimiter(goff.RateLimit{PerHour: 2, FailFast: true})
+	exporter.WatchRateLimiter("test", limiter)
+
+	limiter.Wait()
+	limiter.Wait()
+	limiter.Wait()
+
+	metrics := scrape(t, exporter)
+	assertMetric(t, metrics, `goff_rate_limiter_rejections_total{limiter="test"} 1`)
+	assertMetric(t, metrics, `goff_rate_limiter_waits_total{limiter="test"} 0`)
+	assertMetric(t, metrics, `goff_rate_limiter_remaining{limiter="test",window="hour"} 0`)
+	if strings.Contains(metrics, `window="second"`) {
+		t.Fatalf("Unconfigured limit exported:\n%s", metrics)
+	}
+}
+
+//
+// Mocks
+//
+
+var leagueXMLContent = `<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xml:lang="en-US" yahoo:uri="http://fantasysports.yahooapis.com/fantasy/v2/league/223.l.431" xmlns:yahoo="http://www.yahooapis.com/v1/base.rng" time="181.80584907532ms" copyright="Data provided by Yahoo! and STATS, LLC" refresh_rate="60" xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <league>
+    <league_key>223.l.431</league_key>
+    <league_id>431</league_id>
+    <name>Test League</name>
+  </league>
+</fantasy_content>`
+
+type mockHTTPClient struct {
+	StatusCode int
+	Body       string
+}
+
+func (m *mockHTTPClient) Get(url string) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: m.StatusCode,
+		Status:     http.StatusText(m.StatusCode),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(m.Body)),
+	}, nil
+}
+
+// scrape returns the metrics served by the exporter's handler
+func scrape(t *testing.T, exporter *Exporter) string {
+	request := httptest.NewRequest("GET", "/metrics", nil)
+	recorder := httptest.NewRecorder()
+	exporter.Handler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Unexpected status scraping metrics: %d", recorder.Code)
+	}
+	return recorder.Body.String()
+}
+
+func assertMetric(t *testing.T, metrics string, expected string) {
+	for _, line := range strings.Split(metrics, "\n") {
+		if line == expected {
+			return
+		}
+	}
+	t.Fatalf("Metric not exported\n\texpected: %s\n\tactual:\n%s",
+		expected,
+		metrics)
+}
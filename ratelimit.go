@@ -0,0 +1,272 @@
+package goff
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request is not made because it would
+// exceed the configured rate limit.
+var ErrRateLimited = errors.New(
+	"request would exceed the rate limit for the fantasy sports API")
+
+// ErrRequestUnsupported is returned when a request can't be sent without
+// losing part of it. A HTTPClient that does not implement HTTPRequestClient can
+// only send GET requests without a body or headers, so the clients created by
+// this package to wrap one, such as rate limited, measured, and middleware
+// clients, return this error for any other request instead of sending it.
+var ErrRequestUnsupported = errors.New(
+	"HTTP client can't send arbitrary requests")
+
+// RateLimit configures the amount of requests that can be made to the Yahoo
+// fantasy sports API.
+type RateLimit struct {
+	// Maximum amount of requests per second, or zero for no limit
+	PerSecond int
+	// Maximum amount of requests per hour, or zero for no limit
+	PerHour int
+	// Return ErrRateLimited instead of waiting when the limit is reached
+	FailFast bool
+}
+
+// RateLimitBudget is the amount of requests that can currently be made
+// without exceeding a RateLimit. Limits that are not configured are reported
+// as -1.
+type RateLimitBudget struct {
+	PerSecond int
+	PerHour   int
+}
+
+// RateLimiterStats describes how often requests have been delayed or rejected
+// by a RateLimiter.
+type RateLimiterStats struct {
+	// Amount of requests that waited before being made
+	Waits int64
+	// Total time spent waiting
+	WaitTime time.Duration
+	// Amount of requests rejected with ErrRateLimited
+	Rejections int64
+}
+
+// RateLimiter limits the rate of requests made by one or more HTTPClients
+// by counting the requests made during the last second and hour, so no more
+// than the configured limit is made during any second or hour. Yahoo enforces
+// its limits for an entire application, so a single RateLimiter should be
+// shared by all clients created for an application. RateLimiter is safe for
+// concurrent use.
+type RateLimiter struct {
+	limit     RateLimit
+	perSecond *slidingWindow
+	perHour   *slidingWindow
+
+	mutex sync.Mutex
+	stats RateLimiterStats
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// slidingWindow allows up to a maximum amount of requests during any interval
+// of a fixed length by remembering when the most recent requests were made.
+type slidingWindow struct {
+	limit    int
+	interval time.Duration
+	// Times of the requests made during the last interval, oldest first
+	times []time.Time
+}
+
+// rateLimitedHTTPClient implements HTTPRequestClient and waits for its
+// RateLimiter before making each request.
+type rateLimitedHTTPClient struct {
+	limiter *RateLimiter
+	client  HTTPClient
+}
+
+// NewRateLimiter creates a RateLimiter that allows requests up to the given
+// limit.
+//
+// See RateLimiter.Client
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:     limit,
+		perSecond: newSlidingWindow(limit.PerSecond, time.Second),
+		perHour:   newSlidingWindow(limit.PerHour, time.Hour),
+		now:       time.Now,
+		sleep:     sleepContext,
+	}
+}
+
+// Client returns a HTTPClient that makes requests using the given client
+// while respecting the limits of this RateLimiter. The returned client can be
+// passed to NewClient or NewCachedClient.
+func (l *RateLimiter) Client(client HTTPClient) HTTPRequestClient {
+	return &rateLimitedHTTPClient{limiter: l, client: client}
+}
+
+// Remaining returns the amount of requests that can currently be made without
+// waiting.
+func (l *RateLimiter) Remaining() RateLimitBudget {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	return RateLimitBudget{
+		PerSecond: l.perSecond.remaining(now),
+		PerHour:   l.perHour.remaining(now),
+	}
+}
+
+// Stats returns how often requests have been delayed or rejected by this
+// limiter.
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.stats
+}
+
+// Wait blocks until a request can be made without exceeding the rate limit and
+// then reserves it. If the limiter is configured to fail fast, ErrRateLimited
+// is returned instead of blocking.
+//
+// See WaitContext
+func (l *RateLimiter) Wait() error {
+	return l.WaitContext(context.Background())
+}
+
+// WaitContext is like Wait, but stops waiting and returns the context's error
+// if it is done before a request can be made.
+func (l *RateLimiter) WaitContext(ctx context.Context) error {
+	for waited := false; ; waited = true {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		wait := l.reserve()
+		if wait == 0 {
+			return nil
+		}
+
+		l.mutex.Lock()
+		if l.limit.FailFast {
+			l.stats.Rejections++
+			l.mutex.Unlock()
+			return ErrRateLimited
+		}
+		if !waited {
+			l.stats.Waits++
+		}
+		l.stats.WaitTime += wait
+		l.mutex.Unlock()
+
+		if err := l.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve records a request in every window if none of them are full.
+// Otherwise, no request is recorded and the time until they would all allow
+// a request is returned.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	wait := l.perSecond.wait(now)
+	if hourWait := l.perHour.wait(now); hourWait > wait {
+		wait = hourWait
+	}
+	if wait > 0 {
+		return wait
+	}
+	l.perSecond.take(now)
+	l.perHour.take(now)
+	return 0
+}
+
+// sleepContext waits for the given duration, or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newSlidingWindow creates an empty window allowing the given amount of
+// requests per interval. A nil window, which never limits, is returned for a
+// limit of zero.
+func newSlidingWindow(limit int, interval time.Duration) *slidingWindow {
+	if limit <= 0 {
+		return nil
+	}
+	return &slidingWindow{limit: limit, interval: interval}
+}
+
+// expire forgets the requests made before the last interval.
+func (w *slidingWindow) expire(now time.Time) {
+	expired := 0
+	for expired < len(w.times) &&
+		!now.Before(w.times[expired].Add(w.interval)) {
+
+		expired++
+	}
+	w.times = w.times[expired:]
+}
+
+// wait returns how long until a request is allowed by the window.
+func (w *slidingWindow) wait(now time.Time) time.Duration {
+	if w == nil {
+		return 0
+	}
+	w.expire(now)
+	if len(w.times) < w.limit {
+		return 0
+	}
+	return w.times[0].Add(w.interval).Sub(now)
+}
+
+// take records a request made at the given time.
+func (w *slidingWindow) take(now time.Time) {
+	if w != nil {
+		w.times = append(w.times, now)
+	}
+}
+
+// remaining returns the amount of requests still allowed by the window.
+func (w *slidingWindow) remaining(now time.Time) int {
+	if w == nil {
+		return -1
+	}
+	w.expire(now)
+	return w.limit - len(w.times)
+}
+
+//
+// HTTPClient
+//
+
+// Get waits for the rate limiter before making a GET request to the given URL.
+func (c *rateLimitedHTTPClient) Get(url string) (*http.Response, error) {
+	if err := c.limiter.Wait(); err != nil {
+		return nil, err
+	}
+	return c.client.Get(url)
+}
+
+// Do waits for the rate limiter, or until the request's context is done,
+// before sending the given request. Requests the underlying client can't send
+// are rejected without waiting.
+func (c *rateLimitedHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	if !canSend(c.client, request) {
+		return nil, ErrRequestUnsupported
+	}
+	if err := c.limiter.WaitContext(request.Context()); err != nil {
+		return nil, err
+	}
+	return do(c.client, request)
+}
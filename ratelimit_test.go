@@ -0,0 +1,274 @@
+package goff
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// Test RateLimiter
+//
+
+func TestRateLimiterFailFast(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 2, FailFast: true})
+
+	assertRateLimiterAllows(t, limiter, 2)
+
+	if err := limiter.Wait(); err != ErrRateLimited {
+		t.Fatalf("Unexpected error after exceeding limit\n\t"+
+			"expected: %s\n\tactual: %v",
+			ErrRateLimited,
+			err)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter, clock := mockRateLimiter(RateLimit{PerSecond: 2, FailFast: true})
+
+	assertRateLimiterAllows(t, limiter, 1)
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	assertRateLimiterAllows(t, limiter, 1)
+
+	if err := limiter.Wait(); err != ErrRateLimited {
+		t.Fatalf("Request allowed before a second passed")
+	}
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	assertRateLimiterAllows(t, limiter, 1)
+
+	if err := limiter.Wait(); err != ErrRateLimited {
+		t.Fatalf("Request allowed before a second passed")
+	}
+}
+
+func TestRateLimiterWaits(t *testing.T) {
+	limiter, clock := mockRateLimiter(RateLimit{PerSecond: 4})
+
+	assertRateLimiterAllows(t, limiter, 4)
+	if err := limiter.Wait(); err != nil {
+		t.Fatalf("Unexpected error waiting for limiter: %s", err)
+	}
+
+	if len(clock.sleeps) != 1 {
+		t.Fatalf("Unexpected amount of waits: %+v", clock.sleeps)
+	}
+
+	if clock.sleeps[0] != time.Second {
+		t.Fatalf("Unexpected wait\n\texpected: %s\n\tactual: %s",
+			time.Second,
+			clock.sleeps[0])
+	}
+}
+
+func TestRateLimiterWaitContext(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1})
+	limiter.sleep = sleepContext
+
+	assertRateLimiterAllows(t, limiter, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	if err := limiter.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Unexpected error after context was done: %v", err)
+	}
+	assertIntEquals(t, 0, limiter.Remaining().PerSecond)
+}
+
+func TestRateLimiterPerHour(t *testing.T) {
+	limiter, clock := mockRateLimiter(
+		RateLimit{PerSecond: 10, PerHour: 3, FailFast: true})
+
+	assertRateLimiterAllows(t, limiter, 3)
+	clock.now = clock.now.Add(time.Second)
+
+	if err := limiter.Wait(); err != ErrRateLimited {
+		t.Fatalf("Request allowed after exceeding hourly limit")
+	}
+
+	clock.now = clock.now.Add(59 * time.Minute)
+	if err := limiter.Wait(); err != ErrRateLimited {
+		t.Fatalf("Request allowed before an hour passed")
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	assertRateLimiterAllows(t, limiter, 3)
+}
+
+func TestRateLimiterStats(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 4})
+
+	assertRateLimiterAllows(t, limiter, 9)
+
+	stats := limiter.Stats()
+	assertInt64Equals(t, 2, stats.Waits)
+	assertInt64Equals(t, 0, stats.Rejections)
+	if stats.WaitTime != 2*time.Second {
+		t.Fatalf("Unexpected wait time\n\texpected: %s\n\tactual: %s",
+			2*time.Second,
+			stats.WaitTime)
+	}
+}
+
+func TestRateLimiterStatsRejections(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1, FailFast: true})
+
+	limiter.Wait()
+	limiter.Wait()
+	limiter.Wait()
+
+	stats := limiter.Stats()
+	assertInt64Equals(t, 0, stats.Waits)
+	assertInt64Equals(t, 2, stats.Rejections)
+}
+
+func TestRateLimiterRemaining(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 5, PerHour: 100})
+
+	assertRateLimiterAllows(t, limiter, 3)
+
+	budget := limiter.Remaining()
+	assertIntEquals(t, 2, budget.PerSecond)
+	assertIntEquals(t, 97, budget.PerHour)
+}
+
+func TestRateLimiterRemainingUnlimited(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerHour: 100})
+
+	budget := limiter.Remaining()
+	assertIntEquals(t, -1, budget.PerSecond)
+	assertIntEquals(t, 100, budget.PerHour)
+}
+
+func TestRateLimiterConcurrentUse(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{PerHour: 50, FailFast: true})
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if limiter.Wait() == nil {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	assertIntEquals(t, 50, allowed)
+}
+
+//
+// Test rateLimitedHTTPClient
+//
+
+func TestRateLimitedHTTPClientGet(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1, FailFast: true})
+	expected := &http.Response{}
+	httpClient := &mockHTTPClient{Response: expected}
+	client := limiter.Client(httpClient)
+
+	response, err := client.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("received unexpected response from client")
+	}
+
+	_, err = client.Get("http://example.com")
+	if err != ErrRateLimited {
+		t.Fatalf("Unexpected error after exceeding limit: %v", err)
+	}
+	assertIntEquals(t, 1, httpClient.RequestCount)
+}
+
+func TestRateLimitedHTTPClientDo(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1, FailFast: true})
+	httpClient := &mockHTTPClient{Response: &http.Response{}}
+	client := limiter.Client(httpClient)
+
+	request, _ := http.NewRequest("GET", "http://example.com", nil)
+	_, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("error retrieving response: %s", err)
+	}
+
+	if httpClient.LastRequest != request {
+		t.Fatalf("request not sent to client")
+	}
+
+	_, err = client.Do(request)
+	if err != ErrRateLimited {
+		t.Fatalf("Unexpected error after exceeding limit: %v", err)
+	}
+}
+
+func TestRateLimitedHTTPClientDoUnsupported(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1, FailFast: true})
+	httpClient := &mockGetHTTPClient{&mockHTTPClient{Response: &http.Response{}}}
+	client := limiter.Client(httpClient)
+
+	request, _ := http.NewRequest("POST", "http://example.com", nil)
+	if _, err := client.Do(request); err != ErrRequestUnsupported {
+		t.Fatalf("Unexpected error for unsupported request: %v", err)
+	}
+	assertIntEquals(t, 1, limiter.Remaining().PerSecond)
+}
+
+func TestRateLimitedHTTPClientSharedLimit(t *testing.T) {
+	limiter, _ := mockRateLimiter(RateLimit{PerSecond: 1, FailFast: true})
+	client1 := NewClient(limiter.Client(
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)}))
+	client2 := NewClient(limiter.Client(
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)}))
+
+	if _, err := client1.GetFantasyContent("http://example.com"); err != nil {
+		t.Fatalf("Unexpected error for first client: %s", err)
+	}
+
+	_, err := client2.GetFantasyContent("http://example.com")
+	if err != ErrRateLimited {
+		t.Fatalf("Limit not shared between clients: %v", err)
+	}
+}
+
+//
+// Mocks
+//
+
+type mockClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// mockRateLimiter creates a RateLimiter using a clock that only advances when
+// the limiter sleeps or the returned clock is updated.
+func mockRateLimiter(limit RateLimit) (*RateLimiter, *mockClock) {
+	clock := &mockClock{now: time.Unix(1408281677, 0)}
+	limiter := NewRateLimiter(limit)
+	limiter.now = func() time.Time {
+		return clock.now
+	}
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		clock.sleeps = append(clock.sleeps, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+	return limiter, clock
+}
+
+func assertRateLimiterAllows(t *testing.T, limiter *RateLimiter, requests int) {
+	for i := 0; i < requests; i++ {
+		if err := limiter.Wait(); err != nil {
+			t.Fatalf("Request %d of %d not allowed: %s", i+1, requests, err)
+		}
+	}
+}
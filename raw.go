@@ -0,0 +1,72 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrRawUnavailable is returned by GetRawFantasyContent when the client's
+// Provider does not keep the responses its content is decoded from.
+var ErrRawUnavailable = errors.New(
+	"raw response is not available from the content provider")
+
+// RawResponse is the unmodified response that fantasy content was decoded
+// from. It allows fields that are not part of FantasyContent to be read and
+// exact responses to be archived.
+//
+// Raw responses are cached along with their content, and may be shared by
+// every caller requesting the same URL. They must not be modified.
+type RawResponse struct {
+	// HTTP status code of the response
+	StatusCode int
+	// Headers of the response
+	Header http.Header
+	// Body of the response, either XML or JSON depending on the format
+	// requested by the client
+	Body []byte
+}
+
+// rawCache is a Cache that can tell content cached along with its raw
+// response apart from content cached without one, so that requests for raw
+// responses are not counted as hits when the content has to be retrieved
+// again.
+type rawCache interface {
+	getRaw(url string, time time.Time) (*FantasyContent, bool)
+}
+
+// GetRawFantasyContent directly access Yahoo fantasy resources, returning
+// both the decoded content and the raw response it was decoded from.
+//
+// When using a cache, content cached without its raw response is retrieved
+// again, and the raw response is cached along with the content. The amount
+// of memory used by cached content therefore includes the size of the raw
+// responses.
+//
+// ErrRawUnavailable is returned if the client's Provider does not support raw
+// responses.
+func (c *Client) GetRawFantasyContent(
+	url string) (*FantasyContent, *RawResponse, error) {
+
+	t := newTrace(c.Tracer, c.Logger, "goff.GetRawFantasyContent", url)
+	t.keepRaw = true
+	content, err := getTraced(c.Provider, url, t)
+	if err == nil && content.raw == nil {
+		err = ErrRawUnavailable
+	}
+	t.finish(err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, content.raw, nil
+}
+
+// newRawResponse records the status and headers of the response along with
+// its body.
+func newRawResponse(response *http.Response, body []byte) *RawResponse {
+	return &RawResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+	}
+}
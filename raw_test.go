@@ -0,0 +1,128 @@
+package goff
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test GetRawFantasyContent
+//
+
+func TestGetRawFantasyContent(t *testing.T) {
+	response := mockResponse(teamXMLContent)
+	response.StatusCode = http.StatusOK
+	response.Header = http.Header{"X-Test": []string{"value"}}
+	client := NewClient(&mockHTTPClient{Response: response})
+
+	content, raw, err := client.GetRawFantasyContent("http://example.com/team")
+	if err != nil {
+		t.Fatalf("error retrieving raw content: %s", err)
+	}
+	assertTeamsEqual(t, &expectedTeam, &content.Team)
+	assertStringEquals(t, teamXMLContent, string(raw.Body))
+	assertStringEquals(t, "value", raw.Header.Get("X-Test"))
+	assertIntEquals(t, http.StatusOK, raw.StatusCode)
+}
+
+func TestGetRawFantasyContentJSON(t *testing.T) {
+	client := NewFormatClient(
+		&mockHTTPClient{Response: mockResponse(leagueJSONContent)},
+		FormatJSON)
+
+	_, raw, err := client.GetRawFantasyContent("http://example.com/league")
+	if err != nil {
+		t.Fatalf("error retrieving raw content: %s", err)
+	}
+	assertStringEquals(t, leagueJSONContent, string(raw.Body))
+}
+
+func TestGetFantasyContentDoesNotKeepRaw(t *testing.T) {
+	client := NewClient(
+		&mockHTTPClient{Response: mockResponse(teamXMLContent)})
+
+	content, err := client.GetFantasyContent("http://example.com/team")
+	if err != nil {
+		t.Fatalf("error retrieving content: %s", err)
+	}
+	if content.raw != nil {
+		t.Fatalf("raw response kept when not requested")
+	}
+}
+
+func TestGetRawFantasyContentCached(t *testing.T) {
+	httpClient := &mockHTTPClient{}
+	client := NewCachedClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			httpClient.Get(url)
+			return mockResponse(teamXMLContent), nil
+		}))
+	url := "http://example.com/team"
+
+	client.GetFantasyContent(url)
+	_, raw, err := client.GetRawFantasyContent(url)
+	if err != nil {
+		t.Fatalf("error retrieving raw content: %s", err)
+	}
+	assertIntEquals(t, 2, httpClient.RequestCount)
+	assertStringEquals(t, teamXMLContent, string(raw.Body))
+
+	_, cached, err := client.GetRawFantasyContent(url)
+	if err != nil {
+		t.Fatalf("error retrieving cached raw content: %s", err)
+	}
+	content, err := client.GetFantasyContent(url)
+	if err != nil {
+		t.Fatalf("error retrieving cached content: %s", err)
+	}
+	assertIntEquals(t, 2, httpClient.RequestCount)
+	if cached != raw || content.raw != raw {
+		t.Fatalf("raw response not cached")
+	}
+}
+
+func TestGetRawFantasyContentCachedWithoutRawCountsMiss(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	client := NewCachedClient(
+		cache,
+		mockHTTPClientFunc(func(url string) (*http.Response, error) {
+			return mockResponse(teamXMLContent), nil
+		}))
+	url := "http://example.com/team"
+
+	client.GetFantasyContent(url)
+	if _, _, err := client.GetRawFantasyContent(url); err != nil {
+		t.Fatalf("error retrieving raw content: %s", err)
+	}
+	client.GetRawFantasyContent(url)
+
+	stats := cache.Stats()
+	assertInt64Equals(t, 1, stats.Hits)
+	assertInt64Equals(t, 2, stats.Misses)
+}
+
+func TestGetRawFantasyContentUnavailable(t *testing.T) {
+	client := mockClient(&FantasyContent{}, nil)
+
+	_, _, err := client.GetRawFantasyContent("http://example.com/team")
+	if err != ErrRawUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLRUCacheValueSizeIncludesRaw(t *testing.T) {
+	content := &FantasyContent{}
+	size := newLRUCacheValue(content, true).Size()
+
+	content.raw = &RawResponse{
+		Header: http.Header{"Etag": []string{"abc"}},
+		Body:   []byte(teamXMLContent),
+	}
+	if newLRUCacheValue(content, true).Size() < size+len(teamXMLContent) {
+		t.Fatalf("raw response not included in the size of the content")
+	}
+}
@@ -0,0 +1,277 @@
+package goff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StatusYahooThrottled is the non-standard status code returned by Yahoo when
+// an application has made too many requests.
+const StatusYahooThrottled = 999
+
+// RetryPolicy determines which failed requests to the fantasy sports API are
+// retried and how long to wait between attempts. The wait grows exponentially
+// with each attempt, unless the API responds with a "Retry-After" header.
+type RetryPolicy struct {
+	// Maximum amount of attempts made for a single request, including the
+	// first. A value of one or less disables retries, returning the response
+	// or error of the first attempt as is.
+	MaxAttempts int
+	// Time to wait before the first retry
+	InitialInterval time.Duration
+	// Maximum time to wait between two attempts, including waits requested by
+	// the API with a "Retry-After" header
+	MaxInterval time.Duration
+	// Factor the wait is multiplied by after each attempt
+	Multiplier float64
+	// Fraction of the wait that is randomized, between 0 and 1, to avoid many
+	// clients retrying at the same time
+	Jitter float64
+	// Maximum total time spent on a single request, or zero for no limit
+	MaxElapsedTime time.Duration
+	// Determines whether a response or error should be retried. When nil,
+	// RetryableResponse is used.
+	Retryable func(response *http.Response, err error) bool
+	// Called before waiting to retry a failed request for the URL, if set
+	OnRetry func(url string, attempt RetryAttempt)
+}
+
+// RetryAttempt describes a single failed attempt to make a request.
+type RetryAttempt struct {
+	// Status code of the response, or zero if no response was received
+	StatusCode int
+	// Error returned when making the request, if any
+	Err error
+	// Time waited after this attempt before trying again
+	Wait time.Duration
+}
+
+// RetryError is returned when a request fails after it has been retried. It
+// records every attempt that was made.
+type RetryError struct {
+	Attempts []RetryAttempt
+	// The error that caused the final attempt to fail
+	Err error
+}
+
+// DefaultRetryPolicy is used by clients that have not been configured with
+// their own RetryPolicy. It immediately retries requests failing with the
+// known issue where Yahoo returns "consumer_key_unknown" for valid consumer
+// keys, up to four times, and doesn't retry any other failures.
+//
+// See NewBackoffRetryPolicy to retry other failures.
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: 5,
+	Retryable:   consumerKeyUnknown,
+}
+
+// NewBackoffRetryPolicy creates a RetryPolicy that retries any failure
+// reported by RetryableResponse with exponential backoff, for use with
+// WithRetryPolicy.
+func NewBackoffRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     5,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.5,
+		MaxElapsedTime:  time.Minute,
+	}
+}
+
+// RetryableResponse reports whether the given response or error is likely to
+// succeed if the request is retried. This includes network timeouts,
+// temporary network errors, connections reset by the server, server errors,
+// throttling by the API, and the known issue where Yahoo returns
+// "consumer_key_unknown" for valid consumer keys. Requests that were canceled
+// or whose deadline passed are never retried.
+//
+// See https://developer.yahoo.com/forum/OAuth-General-Discussion-YDN-SDKs/oauth-problem-consumer-key-unknown-/1375188859720-5cea9bdb-0642-4606-9fd5-c5f369112959
+func RetryableResponse(response *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) ||
+			errors.Is(err, context.DeadlineExceeded) {
+
+			return false
+		}
+		return consumerKeyUnknown(response, err) || transientNetworkError(err)
+	}
+	if response == nil {
+		return false
+	}
+	return response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode == StatusYahooThrottled ||
+		response.StatusCode >= http.StatusInternalServerError
+}
+
+// consumerKeyUnknown reports whether the request failed with the known issue
+// where "consumer_key_unknown" is returned for valid consumer keys.
+//
+// See https://developer.yahoo.com/forum/OAuth-General-Discussion-YDN-SDKs/oauth-problem-consumer-key-unknown-/1375188859720-5cea9bdb-0642-4606-9fd5-c5f369112959
+func consumerKeyUnknown(response *http.Response, err error) bool {
+	return err != nil && strings.Contains(err.Error(), "consumer_key_unknown")
+}
+
+// transientNetworkError reports whether the error is a network timeout, a
+// temporary network error, or a connection reset by the server. Other errors,
+// such as an unsupported URL scheme or an invalid certificate, would fail
+// again.
+func transientNetworkError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		return false
+	}
+	// Temporary is deprecated, but still reported by errors such as those
+	// returned when accepting too many connections.
+	temporary, ok := netErr.(interface{ Temporary() bool })
+	return netErr.Timeout() || (ok && temporary.Temporary())
+}
+
+// Error describes the final failure and the amount of attempts made.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("request failed after %d attempts: %s",
+		len(e.Attempts),
+		e.Err)
+}
+
+// Unwrap returns the error that caused the final attempt to fail.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether the response or error should be retried
+func (p *RetryPolicy) retryable(response *http.Response, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(response, err)
+	}
+	return RetryableResponse(response, err)
+}
+
+// wait returns how long to wait after the given attempt, starting at one,
+// failed with the given response. The random value, between 0 and 1, is used
+// to apply jitter. The wait, including one requested by the response, is
+// limited by the policy's MaxInterval.
+func (p *RetryPolicy) wait(
+	attempt int,
+	response *http.Response,
+	random float64) time.Duration {
+
+	if retryAfter, ok := parseRetryAfter(response); ok {
+		if p.MaxInterval > 0 && retryAfter > p.MaxInterval {
+			return p.MaxInterval
+		}
+		return retryAfter
+	}
+
+	multiplier := math.Max(p.Multiplier, 1)
+	interval := float64(p.InitialInterval) *
+		math.Pow(multiplier, float64(attempt-1))
+
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	interval *= 1 + jitter*(2*random-1)
+	if p.MaxInterval > 0 {
+		interval = math.Min(interval, float64(p.MaxInterval))
+	}
+	return time.Duration(interval)
+}
+
+// parseRetryAfter returns the wait requested by the "Retry-After" header of
+// the response, given either in seconds or as a HTTP date.
+func parseRetryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(time.Now())
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// failure returns the error describing why a request failed.
+func failure(response *http.Response, err error) error {
+	if err != nil || response == nil {
+		return err
+	}
+	return fmt.Errorf("unexpected response status: %s", response.Status)
+}
+
+// retry makes a request for the URL using the given function, retrying it
+// according to the policy. The sleep function is used to wait between
+// attempts, and the context's error is returned if it is done while waiting.
+func (p *RetryPolicy) retry(
+	ctx context.Context,
+	url string,
+	request func() (*http.Response, error),
+	sleep func(ctx context.Context, d time.Duration) error) (
+	*http.Response,
+	error) {
+
+	if p.MaxAttempts <= 1 {
+		return request()
+	}
+
+	start := time.Now()
+	attempts := make([]RetryAttempt, 0)
+	for {
+		response, err := request()
+		if !p.retryable(response, err) {
+			if err != nil && len(attempts) > 0 {
+				attempts = append(attempts, RetryAttempt{Err: err})
+				err = &RetryError{Attempts: attempts, Err: err}
+			}
+			return response, err
+		}
+
+		attempt := RetryAttempt{Err: err}
+		if response != nil {
+			attempt.StatusCode = response.StatusCode
+		}
+		attempt.Wait = p.wait(len(attempts)+1, response, rand.Float64())
+		attempts = append(attempts, attempt)
+
+		if len(attempts) >= p.MaxAttempts ||
+			(p.MaxElapsedTime > 0 &&
+				time.Since(start)+attempt.Wait > p.MaxElapsedTime) {
+
+			attempts[len(attempts)-1].Wait = 0
+			err = failure(response, err)
+			if response != nil && response.Body != nil {
+				response.Body.Close()
+			}
+			return nil, &RetryError{Attempts: attempts, Err: err}
+		}
+
+		if response != nil && response.Body != nil {
+			response.Body.Close()
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(url, attempt)
+		}
+		if err := sleep(ctx, attempt.Wait); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,610 @@
+package goff
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//
+// Test RetryableResponse
+//
+
+func TestRetryableResponse(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com", Err: err}
+	}
+	timeoutErr := urlErr(&net.DNSError{Err: "timeout", IsTimeout: true})
+	temporaryErr := urlErr(&net.DNSError{Err: "busy", IsTemporary: true})
+	resetErr := urlErr(&net.OpError{
+		Op:  "read",
+		Err: os.NewSyscallError("read", syscall.ECONNRESET),
+	})
+	refusedErr := urlErr(&net.OpError{
+		Op:  "dial",
+		Err: os.NewSyscallError("connect", syscall.ECONNREFUSED),
+	})
+	schemeErr := urlErr(errors.New(`unsupported protocol scheme "ftp"`))
+	certificateErr := urlErr(x509.UnknownAuthorityError{})
+	tests := []struct {
+		response *http.Response
+		err      error
+		expected bool
+	}{
+		{&http.Response{StatusCode: http.StatusOK}, nil, false},
+		{&http.Response{StatusCode: http.StatusNotModified}, nil, false},
+		{&http.Response{StatusCode: http.StatusNotFound}, nil, false},
+		{&http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{&http.Response{StatusCode: StatusYahooThrottled}, nil, true},
+		{&http.Response{StatusCode: http.StatusInternalServerError}, nil, true},
+		{&http.Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		{nil, errors.New("consumer_key_unknown"), true},
+		{nil, errors.New("You are not allowed to view this page"), false},
+		{nil, errors.New("error"), false},
+		{nil, timeoutErr, true},
+		{nil, temporaryErr, true},
+		{nil, resetErr, true},
+		{nil, refusedErr, false},
+		{nil, schemeErr, false},
+		{nil, certificateErr, false},
+		{nil, urlErr(context.Canceled), false},
+		{nil, urlErr(context.DeadlineExceeded), false},
+		{nil, nil, false},
+	}
+
+	for _, test := range tests {
+		actual := RetryableResponse(test.response, test.err)
+		if actual != test.expected {
+			t.Fatalf("Unexpected result for response: %+v, err: %v\n\t"+
+				"expected: %t\n\tactual: %t",
+				test.response,
+				test.err,
+				test.expected,
+				actual)
+		}
+	}
+}
+
+func TestDefaultRetryPolicyRetryable(t *testing.T) {
+	tests := []struct {
+		response *http.Response
+		err      error
+		expected bool
+	}{
+		{nil, errors.New("consumer_key_unknown"), true},
+		{&http.Response{StatusCode: StatusYahooThrottled}, nil, false},
+		{&http.Response{StatusCode: http.StatusInternalServerError}, nil, false},
+		{nil, &net.OpError{Op: "dial", Err: errors.New("refused")}, false},
+	}
+
+	for _, test := range tests {
+		actual := DefaultRetryPolicy.retryable(test.response, test.err)
+		if actual != test.expected {
+			t.Fatalf("Unexpected result for response: %+v, err: %v\n\t"+
+				"expected: %t\n\tactual: %t",
+				test.response,
+				test.err,
+				test.expected,
+				actual)
+		}
+	}
+}
+
+func TestDefaultRetryPolicyDoesNotWait(t *testing.T) {
+	if wait := DefaultRetryPolicy.wait(1, nil, 1); wait != 0 {
+		t.Fatalf("Unexpected wait: %s", wait)
+	}
+}
+
+//
+// Test RetryPolicy
+//
+
+func TestRetryPolicyWaitExponential(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, wait := range expected {
+		actual := policy.wait(i+1, nil, 0.5)
+		if actual != wait {
+			t.Fatalf("Unexpected wait for attempt %d\n\texpected: %s\n\t"+
+				"actual: %s",
+				i+1,
+				wait,
+				actual)
+		}
+	}
+}
+
+func TestRetryPolicyWaitJitter(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+
+	if wait := policy.wait(1, nil, 0); wait != 50*time.Millisecond {
+		t.Fatalf("Unexpected minimum wait: %s", wait)
+	}
+
+	if wait := policy.wait(1, nil, 1); wait != 150*time.Millisecond {
+		t.Fatalf("Unexpected maximum wait: %s", wait)
+	}
+}
+
+func TestRetryPolicyWaitJitterMaxInterval(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     time.Second,
+		Jitter:          0.5,
+	}
+
+	if wait := policy.wait(1, nil, 1); wait != time.Second {
+		t.Fatalf("Wait with jitter exceeded maximum interval: %s", wait)
+	}
+}
+
+func TestRetryPolicyWaitRetryAfterSeconds(t *testing.T) {
+	policy := &RetryPolicy{InitialInterval: 100 * time.Millisecond}
+	response := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}
+
+	if wait := policy.wait(1, response, 0.5); wait != 30*time.Second {
+		t.Fatalf("Retry-After not used for wait: %s", wait)
+	}
+}
+
+func TestRetryPolicyWaitRetryAfterMaxInterval(t *testing.T) {
+	policy := &RetryPolicy{MaxInterval: 10 * time.Second}
+	response := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3600"}},
+	}
+
+	if wait := policy.wait(1, response, 0.5); wait != 10*time.Second {
+		t.Fatalf("Retry-After not limited by MaxInterval: %s", wait)
+	}
+}
+
+func TestRetryPolicyWaitRetryAfterDate(t *testing.T) {
+	policy := &RetryPolicy{InitialInterval: 100 * time.Millisecond}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	response := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{date}},
+	}
+
+	wait := policy.wait(1, response, 0.5)
+	if wait <= 58*time.Second || wait > time.Minute {
+		t.Fatalf("Retry-After date not used for wait: %s", wait)
+	}
+}
+
+func TestRetryPolicyRetriesUntilSuccess(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:     5,
+		InitialInterval: 100 * time.Millisecond,
+		Multiplier:      2,
+	}
+	expected := &http.Response{StatusCode: http.StatusOK}
+	requests := mockRequests(
+		&http.Response{StatusCode: http.StatusServiceUnavailable, Body: &mockReaderCloser{}},
+		&http.Response{StatusCode: StatusYahooThrottled, Body: &mockReaderCloser{}},
+		expected)
+	sleeps := make([]time.Duration, 0)
+
+	response, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		func(ctx context.Context, d time.Duration) error {
+			sleeps = append(sleeps, d)
+			return nil
+		})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+
+	assertIntEquals(t, 3, requests.count)
+	assertIntEquals(t, 2, len(sleeps))
+	if sleeps[0] != 100*time.Millisecond || sleeps[1] != 200*time.Millisecond {
+		t.Fatalf("Unexpected waits between attempts: %+v", sleeps)
+	}
+
+	for i := 0; i < 2; i++ {
+		body := requests.responses[i].Body.(*mockReaderCloser)
+		if !body.WasClosed {
+			t.Fatalf("Body of retried response %d not closed", i)
+		}
+	}
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3}
+	body := &mockReaderCloser{}
+	requests := mockRequests(
+		&http.Response{StatusCode: http.StatusBadGateway},
+		&http.Response{StatusCode: http.StatusBadGateway},
+		&http.Response{
+			StatusCode: http.StatusBadGateway,
+			Status:     "502 Bad Gateway",
+			Body:       body,
+		})
+
+	response, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	if response != nil {
+		t.Fatalf("Response returned after retries failed: %+v", response)
+	}
+
+	retryErr, ok := err.(*RetryError)
+	if !ok {
+		t.Fatalf("Unexpected error type: %T", err)
+	}
+
+	assertIntEquals(t, 3, requests.count)
+	assertIntEquals(t, 3, len(retryErr.Attempts))
+	for _, attempt := range retryErr.Attempts {
+		assertIntEquals(t, http.StatusBadGateway, attempt.StatusCode)
+	}
+	if retryErr.Attempts[2].Wait != 0 {
+		t.Fatalf("Wait recorded for final attempt: %s", retryErr.Attempts[2].Wait)
+	}
+	assertStringEquals(t,
+		"request failed after 3 attempts: "+
+			"unexpected response status: 502 Bad Gateway",
+		retryErr.Error())
+
+	if !body.WasClosed {
+		t.Fatal("Body of final response not closed")
+	}
+}
+
+func TestRetryPolicySingleAttempt(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 1}
+	expected := &http.Response{StatusCode: http.StatusBadGateway}
+	requests := mockRequests(expected)
+
+	response, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if response != expected {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+
+	requestErr := errors.New("consumer_key_unknown")
+	requests = mockRequestErrors(requestErr)
+	_, err = policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	if err != requestErr {
+		t.Fatalf("Unexpected error\n\texpected: %s\n\tactual: %s",
+			requestErr,
+			err)
+	}
+}
+
+func TestRetryPolicyContextDone(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialInterval: time.Hour}
+	requests := mockRequests(
+		&http.Response{StatusCode: http.StatusServiceUnavailable},
+		&http.Response{StatusCode: http.StatusServiceUnavailable})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := policy.retry(ctx, "http://example.com", requests.next, sleepContext)
+
+	if err != context.Canceled {
+		t.Fatalf("Unexpected error after context was done: %v", err)
+	}
+	assertIntEquals(t, 1, requests.count)
+}
+
+func TestRetryPolicyMaxElapsedTime(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:     10,
+		InitialInterval: time.Minute,
+		MaxElapsedTime:  30 * time.Second,
+	}
+	requestErr := errors.New("consumer_key_unknown")
+	requests := mockRequestErrors(requestErr, requestErr)
+
+	_, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		func(context.Context, time.Duration) error {
+			t.Fatal("Waited longer than maximum elapsed time")
+			return nil
+		})
+
+	retryErr, ok := err.(*RetryError)
+	if !ok {
+		t.Fatalf("Unexpected error type: %T", err)
+	}
+	assertIntEquals(t, 1, requests.count)
+	if retryErr.Err != requestErr {
+		t.Fatalf("Unexpected final error: %s", retryErr.Err)
+	}
+}
+
+func TestRetryPolicyDoesNotRetryPermanentErrors(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5}
+	expected := errors.New("error")
+	requests := mockRequestErrors(expected)
+
+	_, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	if err != expected {
+		t.Fatalf("Unexpected error\n\texpected: %s\n\tactual: %s",
+			expected,
+			err)
+	}
+	assertIntEquals(t, 1, requests.count)
+}
+
+func TestRetryPolicyPermanentErrorAfterRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5}
+	expected := errors.New("error")
+	requests := mockRequestErrors(errors.New("consumer_key_unknown"), expected)
+
+	_, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	retryErr, ok := err.(*RetryError)
+	if !ok {
+		t.Fatalf("Unexpected error type: %T", err)
+	}
+	assertIntEquals(t, 2, len(retryErr.Attempts))
+	if retryErr.Unwrap() != expected {
+		t.Fatalf("Unexpected final error: %s", retryErr.Unwrap())
+	}
+}
+
+func TestRetryPolicyOnRetry(t *testing.T) {
+	retries := make([]RetryAttempt, 0)
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		OnRetry: func(url string, attempt RetryAttempt) {
+			assertStringEquals(t, "http://example.com", url)
+			retries = append(retries, attempt)
+		},
+	}
+	requests := mockRequests(
+		&http.Response{StatusCode: http.StatusBadGateway},
+		&http.Response{StatusCode: http.StatusServiceUnavailable},
+		&http.Response{StatusCode: http.StatusOK})
+
+	policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	assertIntEquals(t, 2, len(retries))
+	assertIntEquals(t, http.StatusBadGateway, retries[0].StatusCode)
+	assertIntEquals(t, http.StatusServiceUnavailable, retries[1].StatusCode)
+}
+
+func TestRetryPolicyCustomRetryable(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts: 5,
+		Retryable: func(response *http.Response, err error) bool {
+			return response.StatusCode == http.StatusNotFound
+		},
+	}
+	expected := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	requests := mockRequests(
+		&http.Response{StatusCode: http.StatusNotFound},
+		expected)
+
+	response, err := policy.retry(
+		context.Background(),
+		"http://example.com",
+		requests.next,
+		noSleep)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if response != expected {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+	assertIntEquals(t, 2, requests.count)
+}
+
+//
+// Test countingHTTPApiClient retries
+//
+
+func TestCountingHTTPClientRetryPolicy(t *testing.T) {
+	sleeps := 0
+	client := &countingHTTPApiClient{
+		client: &mockHTTPClient{
+			Response:   &http.Response{},
+			Error:      errors.New("consumer_key_unknown"),
+			ErrorCount: 2,
+		},
+		retryPolicy: &RetryPolicy{MaxAttempts: 2},
+		sleep: func(context.Context, time.Duration) error {
+			sleeps++
+			return nil
+		},
+	}
+
+	_, err := client.Get("http://example.com")
+
+	if _, ok := err.(*RetryError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertIntEquals(t, 2, client.RequestCount())
+	assertIntEquals(t, 1, sleeps)
+}
+
+func TestCountingHTTPClientRetryAccessDenied(t *testing.T) {
+	client := &countingHTTPApiClient{
+		client: &mockHTTPClient{
+			Response:   &http.Response{},
+			Error:      errors.New("You are not allowed to view this page"),
+			ErrorCount: 1,
+		},
+		retryPolicy: &RetryPolicy{MaxAttempts: 5},
+		sleep:       noSleep,
+	}
+
+	_, err := client.Get("http://example.com")
+
+	if err != ErrAccessDenied {
+		t.Fatalf("Unexpected error\n\texpected: %s\n\tactual: %v",
+			ErrAccessDenied,
+			err)
+	}
+	assertIntEquals(t, 1, client.RequestCount())
+}
+
+func TestCountingHTTPClientRetryRequestBody(t *testing.T) {
+	httpClient := &mockBodyHTTPClient{status: http.StatusInternalServerError}
+	client := &countingHTTPApiClient{
+		client:      httpClient,
+		retryPolicy: &RetryPolicy{MaxAttempts: 3},
+		sleep:       noSleep,
+	}
+	request, _ := http.NewRequest(
+		"POST",
+		"http://example.com",
+		strings.NewReader("body"))
+
+	client.Do(request)
+
+	assertIntEquals(t, 3, len(httpClient.bodies))
+	for _, body := range httpClient.bodies {
+		assertStringEquals(t, "body", body)
+	}
+}
+
+func TestCountingHTTPClientRetryRequestBodyWithoutGetBody(t *testing.T) {
+	httpClient := &mockBodyHTTPClient{status: http.StatusInternalServerError}
+	client := &countingHTTPApiClient{
+		client:      httpClient,
+		retryPolicy: &RetryPolicy{MaxAttempts: 3},
+		sleep:       noSleep,
+	}
+	request, _ := http.NewRequest(
+		"POST",
+		"http://example.com",
+		strings.NewReader("body"))
+	request.GetBody = nil
+
+	_, err := client.Do(request)
+
+	if err == nil {
+		t.Fatalf("no error returned when the request body can't be resent")
+	}
+	assertIntEquals(t, 1, len(httpClient.bodies))
+}
+
+//
+// Mocks
+//
+
+// mockBodyHTTPClient responds to every request with the given status,
+// recording the body of each request
+type mockBodyHTTPClient struct {
+	status int
+	bodies []string
+}
+
+func (m *mockBodyHTTPClient) Get(url string) (*http.Response, error) {
+	request, _ := http.NewRequest("GET", url, nil)
+	return m.Do(request)
+}
+
+func (m *mockBodyHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(request.Body)
+	m.bodies = append(m.bodies, string(body))
+	return &http.Response{
+		StatusCode: m.status,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+// mockedRequests returns the given responses and errors in order
+type mockedRequests struct {
+	responses []*http.Response
+	errors    []error
+	count     int
+}
+
+func mockRequests(responses ...*http.Response) *mockedRequests {
+	return &mockedRequests{
+		responses: responses,
+		errors:    make([]error, len(responses)),
+	}
+}
+
+func mockRequestErrors(errors ...error) *mockedRequests {
+	return &mockedRequests{
+		responses: make([]*http.Response, len(errors)),
+		errors:    errors,
+	}
+}
+
+func (m *mockedRequests) next() (*http.Response, error) {
+	index := m.count
+	m.count++
+	return m.responses[index], m.errors[index]
+}
+
+// noSleep returns immediately instead of waiting between retries
+func noSleep(ctx context.Context, d time.Duration) error {
+	return nil
+}
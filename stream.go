@@ -0,0 +1,237 @@
+package goff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// streamingContentProvider is a ContentProvider that can decode the elements
+// of a response one at a time, without unmarshalling the whole response.
+type streamingContentProvider interface {
+	ContentProvider
+
+	// Requests the content for the URL and calls fn with each element with
+	// the given name, recording the request and decoding in the trace
+	stream(url string, name string, fn elementFunc, t trace) error
+}
+
+// elementFunc decodes a single element of a streamed response, starting with
+// the given start element, using the decoder.
+type elementFunc func(decoder *xml.Decoder, start xml.StartElement) error
+
+// countingReader counts the bytes read from another io.Reader.
+type countingReader struct {
+	reader io.Reader
+	count  int
+}
+
+//
+// Streaming
+//
+
+// StreamPlayers requests the content for the URL and calls fn with each
+// player in the response as it is decoded, instead of unmarshalling the whole
+// response first. This reduces the memory used by large responses such as
+// every player in a league:
+//
+//    err := client.StreamPlayers(
+//        "https://fantasysports.yahooapis.com/fantasy/v2/league/223.l.431/players",
+//        func(player goff.Player) error {
+//            fmt.Println(player.Name.Full)
+//            return nil
+//        })
+//
+// Players are found anywhere within the response, including the rosters of
+// teams. Streamed responses are not cached. Streaming stops when fn returns
+// an error, which is then returned by StreamPlayers.
+//
+// If the client's Provider can't stream responses, the content is retrieved
+// using the Provider and fn is called with each of its players instead.
+func (c *Client) StreamPlayers(url string, fn func(Player) error) error {
+	t := trace{}
+	if c.Tracer != nil || c.Logger != nil {
+		t = newTrace(c.Tracer, c.Logger, "goff.StreamPlayers", url)
+	}
+
+	streaming, ok := streamingProvider(c.Provider)
+	if !ok {
+		err := forEachPlayer(c.Provider, url, fn, t)
+		t.finish(err)
+		return err
+	}
+
+	err := streaming.stream(
+		url,
+		"player",
+		func(decoder *xml.Decoder, start xml.StartElement) error {
+			var player Player
+			if err := decoder.DecodeElement(&player, &start); err != nil {
+				return err
+			}
+			return fn(player)
+		},
+		t)
+	t.finish(err)
+	return err
+}
+
+// streamingProvider returns the provider if it can stream responses. Cached
+// providers are bypassed in favour of the provider they delegate to.
+func streamingProvider(
+	provider ContentProvider) (streamingContentProvider, bool) {
+
+	if cached, ok := provider.(*cachedContentProvider); ok {
+		provider = cached.delegate
+	}
+	streaming, ok := provider.(streamingContentProvider)
+	return streaming, ok
+}
+
+// forEachPlayer gets the content for the URL from the provider and calls fn
+// with each of its players.
+func forEachPlayer(
+	provider ContentProvider,
+	url string,
+	fn func(Player) error,
+	t trace) error {
+
+	content, err := getTraced(provider, url, t)
+	if err != nil {
+		return err
+	}
+
+	players := append([]Player{}, content.Players...)
+	players = append(players, content.League.Players...)
+	for _, team := range content.League.Teams {
+		players = append(players, teamPlayers(team)...)
+	}
+	players = append(players, teamPlayers(content.Team)...)
+	for _, player := range players {
+		if err := fn(player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teamPlayers returns the players and roster of the team.
+func teamPlayers(team Team) []Player {
+	return append(append([]Player{}, team.Players...), team.Roster.Players...)
+}
+
+// streamContent requests the content for the URL using the client and calls
+// fn with each element with the given name as it is decoded, recording the
+// request and decoding in the trace.
+func streamContent(
+	client httpAPIClient,
+	url string,
+	decoder contentDecoder,
+	name string,
+	fn elementFunc,
+	t trace) error {
+
+	response, err := fetchContent(client, url, t)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	decode := t.start(decoder.span)
+	body := &countingReader{reader: response.Body}
+	err = streamElements(body, decoder, name, fn)
+	decode.set(attributeBytes, body.count)
+	t.setRoot(attributeBytes, body.count)
+	decode.end(err)
+	return err
+}
+
+// streamElements calls fn with each element with the given name in the
+// fantasy content read from the body. Elements nested within a matching
+// element are left to fn.
+func streamElements(
+	body io.Reader,
+	decoder contentDecoder,
+	name string,
+	fn elementFunc) error {
+
+	d, err := decoder.newDecoder(body)
+	if err != nil {
+		return err
+	}
+
+	root := true
+	for {
+		token, err := d.Token()
+		if err == io.EOF && !root {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		switch {
+		case !ok:
+			continue
+		case root && start.Name.Local != "fantasy_content":
+			return fmt.Errorf(
+				"expected element type <fantasy_content> but have <%s>",
+				start.Name.Local)
+		case root:
+			root = false
+		case start.Name.Local == name:
+			if err := fn(d, start); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeContent decodes the fantasy content from the body using the decoder.
+// The rest of the body is read once the content is decoded.
+func decodeContent(
+	body io.Reader,
+	decoder contentDecoder,
+	content *FantasyContent) error {
+
+	d, err := decoder.newDecoder(body)
+	if err != nil {
+		return err
+	}
+	if err := d.Decode(content); err != nil {
+		return err
+	}
+	_, err = io.Copy(ioutil.Discard, body)
+	return err
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += n
+	return n, err
+}
+
+//
+// streamingContentProvider
+//
+
+func (p *xmlContentProvider) stream(
+	url string,
+	name string,
+	fn elementFunc,
+	t trace) error {
+
+	return streamContent(p.client, url, xmlDecoder, name, fn, t)
+}
+
+func (p *jsonContentProvider) stream(
+	url string,
+	name string,
+	fn elementFunc,
+	t trace) error {
+
+	return streamContent(p.client, jsonURL(url), jsonDecoder, name, fn, t)
+}
@@ -0,0 +1,273 @@
+package goff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test StreamPlayers
+//
+
+func TestStreamPlayers(t *testing.T) {
+	client := NewClient(
+		&mockHTTPClient{Response: mockResponse(rosterXMLContent)})
+
+	names, err := streamPlayerNames(client)
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	assertStringEquals(t, "Adrian Peterson,Tom Brady", names)
+}
+
+func TestStreamPlayersJSON(t *testing.T) {
+	httpClient := &mockHTTPClient{Response: mockResponse(rosterJSONContent)}
+	client := NewFormatClient(httpClient, FormatJSON)
+
+	names, err := streamPlayerNames(client)
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	assertStringEquals(t, "Adrian Peterson,Tom Brady", names)
+	assertURLContainsParam(t, httpClient.LastURL, "format", "json")
+}
+
+func TestStreamPlayersFixesPoints(t *testing.T) {
+	client := NewClient(
+		&mockHTTPClient{Response: mockResponse(leaguePlayersXMLContent(2))})
+
+	var points []float64
+	err := client.StreamPlayers(
+		"http://example.com/league/223.l.431/players",
+		func(player Player) error {
+			points = append(points, player.PlayerPoints.Total)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	if len(points) != 2 || points[0] != 0.5 || points[1] != 1.5 {
+		t.Fatalf("unexpected player points: %v", points)
+	}
+}
+
+func TestStreamPlayersStopsOnError(t *testing.T) {
+	client := NewClient(
+		&mockHTTPClient{Response: mockResponse(rosterXMLContent)})
+	expected := errors.New("stop")
+
+	count := 0
+	err := client.StreamPlayers(
+		"http://example.com/team/223.l.431.t.1/roster",
+		func(player Player) error {
+			count++
+			return expected
+		})
+	if err != expected {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertIntEquals(t, 1, count)
+}
+
+func TestStreamPlayersErrorResponse(t *testing.T) {
+	client := NewClient(&mockHTTPClient{Response: mockResponse(
+		`<?xml version="1.0" encoding="UTF-8"?>
+<error><description>Invalid league key</description></error>`)})
+
+	_, err := streamPlayerNames(client)
+	if err == nil {
+		t.Fatalf("no error returned for an error response")
+	}
+}
+
+func TestStreamPlayersRequestError(t *testing.T) {
+	client := NewClient(&mockHTTPClient{
+		Error:      errors.New("error"),
+		ErrorCount: 1,
+	})
+	client.Provider.(*xmlContentProvider).client.(*countingHTTPApiClient).
+		retryPolicy = &RetryPolicy{}
+
+	_, err := streamPlayerNames(client)
+	if err == nil {
+		t.Fatalf("no error returned when the request failed")
+	}
+}
+
+func TestStreamPlayersCachedClient(t *testing.T) {
+	cache := NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024))
+	client := NewCachedClient(
+		cache,
+		&mockHTTPClient{Response: mockResponse(rosterXMLContent)})
+
+	names, err := streamPlayerNames(client)
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	assertStringEquals(t, "Adrian Peterson,Tom Brady", names)
+	assertIntEquals(t, 0, len(cache.Entries("")))
+}
+
+func TestStreamPlayersWithoutStreamingProvider(t *testing.T) {
+	client := mockClient(&FantasyContent{
+		League: League{
+			Players: []Player{Player{Name: Name{Full: "League Player"}}},
+			Teams: []Team{
+				Team{
+					Roster: Roster{
+						Players: []Player{Player{Name: Name{Full: "Rostered"}}},
+					},
+				},
+			},
+		},
+	},
+		nil)
+
+	names, err := streamPlayerNames(client)
+	if err != nil {
+		t.Fatalf("error streaming players: %s", err)
+	}
+	assertStringEquals(t, "League Player,Rostered", names)
+}
+
+func TestStreamPlayersTraced(t *testing.T) {
+	tracer := &mockTracer{}
+	response := mockResponse(rosterXMLContent)
+	response.StatusCode = http.StatusOK
+	client := NewClient(&mockHTTPClient{Response: response})
+	client.Tracer = tracer
+
+	streamPlayerNames(client)
+
+	assertSpanNames(
+		t,
+		tracer.spans,
+		[]string{"goff.StreamPlayers", "goff.http.fetch", "goff.xml.decode"})
+	assertIntEquals(
+		t,
+		len(rosterXMLContent),
+		tracer.spans[0].attributes[attributeBytes].(int))
+}
+
+//
+// Benchmarks
+//
+
+// BenchmarkDecodeReadAll decodes a large response using the previous
+// approach of reading the whole body before unmarshalling it.
+func BenchmarkDecodeReadAll(b *testing.B) {
+	content := leaguePlayersXMLContent(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bits, err := ioutil.ReadAll(benchmarkResponse(content).Body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var fantasyContent FantasyContent
+		if err := xml.Unmarshal(bits, &fantasyContent); err != nil {
+			b.Fatal(err)
+		}
+		fixContent(&fantasyContent)
+	}
+}
+
+// BenchmarkDecodeStreaming decodes a large response directly from the body.
+func BenchmarkDecodeStreaming(b *testing.B) {
+	content := leaguePlayersXMLContent(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := readContent(benchmarkResponse(content), xmlDecoder, trace{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStreamPlayers decodes the players of a large response one at a
+// time.
+func BenchmarkStreamPlayers(b *testing.B) {
+	content := leaguePlayersXMLContent(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client := NewClient(mockHTTPClientFunc(
+			func(url string) (*http.Response, error) {
+				return benchmarkResponse(content), nil
+			}))
+		err := client.StreamPlayers(
+			"http://example.com/league/223.l.431/players",
+			func(player Player) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//
+// Helpers
+//
+
+// streamPlayerNames streams the players for a roster using the client,
+// returning their full names separated by commas.
+func streamPlayerNames(client *Client) (string, error) {
+	var names []string
+	err := client.StreamPlayers(
+		"http://example.com/team/223.l.431.t.1/roster",
+		func(player Player) error {
+			names = append(names, player.Name.Full)
+			return nil
+		})
+	return strings.Join(names, ","), err
+}
+
+func benchmarkResponse(content string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(content)),
+	}
+}
+
+// leaguePlayersXMLContent returns a league response with the given amount of
+// players.
+func leaguePlayersXMLContent(count int) string {
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <league>
+    <league_key>223.l.431</league_key>
+    <players>`)
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&content, `
+      <player>
+        <player_key>223.p.%d</player_key>
+        <player_id>%d</player_id>
+        <name>
+          <full>Player %d</full>
+          <first>Player</first>
+          <last>%d</last>
+        </name>
+        <display_position>WR</display_position>
+        <player_points>
+          <coverage_type>week</coverage_type>
+          <week>16</week>
+          <total>%d.5</total>
+        </player_points>
+      </player>`, i, i, i, i, i)
+	}
+	content.WriteString(`
+    </players>
+  </league>
+</fantasy_content>`)
+	return content.String()
+}
@@ -0,0 +1,155 @@
+package goff
+
+import "regexp"
+
+// Tracer creates spans describing the work done by a Client. Set the Tracer
+// of a Client to trace its requests, for example using the adapter in
+// github.com/e0/goff/otel. Implementations must be safe for concurrent use.
+//
+// Each call to GetFantasyContent or one of the convenience functions creates
+// a root span named after the function, e.g. "goff.GetTeam", with the
+// following child spans, as applicable:
+//
+//    goff.cache.lookup  looking up the content in the cache
+//    goff.http.fetch    making the HTTP request to the API
+//    goff.xml.decode    reading and unmarshalling an XML response
+//    goff.json.decode   reading and unmarshalling a JSON response
+//    goff.fixContent    updating the content after it is unmarshalled
+//
+// The root span records the attributes "goff.resource", "goff.league_key",
+// "goff.cache_hit", "goff.cache_stale", "goff.retries", "goff.bytes", and
+// "http.status_code" when they are known.
+type Tracer interface {
+	// Starts a span with the given name as a child of the parent span, or as
+	// a root span when parent is nil
+	StartSpan(name string, parent Span) Span
+}
+
+// Span describes a single operation traced by a Tracer.
+type Span interface {
+	// Records an attribute of the operation. The value is a string, int, or
+	// bool.
+	SetAttribute(key string, value interface{})
+	// Records that the operation failed with the given error
+	RecordError(err error)
+	// Completes the span
+	End()
+}
+
+// Attributes recorded by the spans of a Client.
+const (
+	attributeResource   = "goff.resource"
+	attributeLeagueKey  = "goff.league_key"
+	attributeCacheHit   = "goff.cache_hit"
+	attributeCacheStale = "goff.cache_stale"
+	attributeBytes      = "goff.bytes"
+	attributeRetries    = "goff.retries"
+	attributeStatusCode = "http.status_code"
+)
+
+// leagueKeyPattern matches league keys, as well as the league key prefix of
+// team keys, within API URLs.
+var leagueKeyPattern = regexp.MustCompile(`\b\d+\.l\.\d+\b`)
+
+// trace records spans as children of a parent span, along with the options of
+// the call being traced. The zero value records nothing.
+type trace struct {
+	tracer Tracer
+	// Span of the current operation
+	span Span
+	// Span created for the Client function that was called
+	root Span
+	// Records the attributes of the root span to be logged, if set
+	log *requestLog
+	// Keep the raw response along with the decoded content
+	keepRaw bool
+	// The content will no longer change, so it is cached regardless of the
+	// current cache period
+	final bool
+}
+
+// tracedContentProvider is a ContentProvider that can record the work done
+// to get content in spans.
+type tracedContentProvider interface {
+	ContentProvider
+
+	// Gets the content for the URL, starting spans as children of the trace
+	get(url string, t trace) (*FantasyContent, error)
+}
+
+// newTrace starts a root span with the given name for a request to the URL.
+// The tracer and logger are both optional.
+func newTrace(tracer Tracer, logger Logger, name string, url string) trace {
+	t := trace{tracer: tracer}
+	if tracer != nil {
+		t.span = tracer.StartSpan(name, nil)
+		t.root = t.span
+	}
+	if logger != nil {
+		t.log = newRequestLog(logger, name, url)
+	}
+	t.setRoot(attributeResource, ResourceType(url))
+	if leagueKey := leagueKeyPattern.FindString(url); leagueKey != "" {
+		t.setRoot(attributeLeagueKey, leagueKey)
+	}
+	return t
+}
+
+// start starts a span with the given name as a child of the trace's span.
+func (t trace) start(name string) trace {
+	if t.tracer == nil {
+		return t
+	}
+	t.span = t.tracer.StartSpan(name, t.span)
+	return t
+}
+
+// set records an attribute of the trace's span.
+func (t trace) set(key string, value interface{}) {
+	if t.span != nil {
+		t.span.SetAttribute(key, value)
+	}
+}
+
+// setRoot records an attribute of the trace's root span.
+func (t trace) setRoot(key string, value interface{}) {
+	if t.root != nil {
+		t.root.SetAttribute(key, value)
+	}
+	if t.log != nil {
+		t.log.attributes[key] = value
+	}
+}
+
+// end completes the trace's span, recording the error if it is not nil.
+func (t trace) end(err error) {
+	if t.span == nil {
+		return
+	}
+	if err != nil {
+		t.span.RecordError(err)
+	}
+	t.span.End()
+}
+
+// finish completes the trace's root span and logs the request, recording the
+// error if it is not nil.
+func (t trace) finish(err error) {
+	t.end(err)
+	if t.log != nil {
+		t.log.write(err)
+	}
+}
+
+// getTraced gets the content for the URL from the provider, recording spans
+// for the trace if the provider supports it.
+func getTraced(
+	provider ContentProvider,
+	url string,
+	t trace) (*FantasyContent, error) {
+
+	if traced, ok := provider.(tracedContentProvider); ok {
+		return traced.get(url, t)
+	}
+	return provider.Get(url)
+}
@@ -0,0 +1,160 @@
+package goff
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	lru "github.com/youtube/vitess/go/cache"
+)
+
+//
+// Test Client tracing
+//
+
+func TestTracedClientGetTeam(t *testing.T) {
+	tracer := &mockTracer{}
+	response := mockResponse(teamXMLContent)
+	response.StatusCode = http.StatusOK
+	client := NewCachedClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		&mockHTTPClient{Response: response})
+	client.Tracer = tracer
+
+	if _, err := client.GetTeam("223.l.431.t.1"); err != nil {
+		t.Fatalf("error retrieving team: %s", err)
+	}
+
+	assertSpanNames(t, tracer.spans, []string{
+		"goff.GetTeam",
+		"goff.cache.lookup",
+		"goff.http.fetch",
+		"goff.xml.decode",
+		"goff.fixContent",
+	})
+
+	root := tracer.spans[0]
+	for _, span := range tracer.spans[1:] {
+		if span.parent != root {
+			t.Fatalf("Span %s is not a child of the root span", span.name)
+		}
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Fatalf("Span %s was not ended", span.name)
+		}
+	}
+
+	assertStringEquals(t, "team", root.attributes[attributeResource].(string))
+	assertStringEquals(t, "223.l.431", root.attributes[attributeLeagueKey].(string))
+	assertBoolEquals(t, false, root.attributes[attributeCacheHit].(bool))
+	assertIntEquals(t, len(teamXMLContent), root.attributes[attributeBytes].(int))
+	assertIntEquals(t, http.StatusOK, tracer.spans[2].attributes[attributeStatusCode].(int))
+}
+
+func TestTracedClientCacheHit(t *testing.T) {
+	tracer := &mockTracer{}
+	client := NewCachedClient(
+		NewLRUCache("clientID", time.Hour, lru.NewLRUCache(1024*1024)),
+		&mockHTTPClient{Response: mockResponse(leagueXMLContent)})
+
+	client.GetLeagueMetadata("223.l.431")
+	client.Tracer = tracer
+	client.GetLeagueMetadata("223.l.431")
+
+	assertSpanNames(t, tracer.spans, []string{
+		"goff.GetLeagueMetadata",
+		"goff.cache.lookup",
+	})
+	assertBoolEquals(t, true, tracer.spans[0].attributes[attributeCacheHit].(bool))
+}
+
+func TestTracedClientError(t *testing.T) {
+	tracer := &mockTracer{}
+	expected := errors.New("error")
+	client := NewClient(&mockHTTPClient{Error: expected, ErrorCount: 1})
+	client.Tracer = tracer
+
+	_, err := client.GetFantasyContent("http://example.com")
+	if err != expected {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertSpanNames(t, tracer.spans, []string{
+		"goff.GetFantasyContent",
+		"goff.http.fetch",
+	})
+	for _, span := range tracer.spans {
+		if span.err != expected {
+			t.Fatalf("Error not recorded by span %s: %v", span.name, span.err)
+		}
+	}
+}
+
+func TestTracedClientUntracedProvider(t *testing.T) {
+	tracer := &mockTracer{}
+	client := mockClient(&FantasyContent{}, nil)
+	client.Tracer = tracer
+
+	client.GetAllTeams("223.l.431")
+
+	assertSpanNames(t, tracer.spans, []string{"goff.GetAllTeams"})
+	assertStringEquals(t, "league", tracer.spans[0].attributes[attributeResource].(string))
+}
+
+//
+// Mocks
+//
+
+// mockTracer records every span it starts
+type mockTracer struct {
+	mutex sync.Mutex
+	spans []*mockSpan
+}
+
+type mockSpan struct {
+	name       string
+	parent     *mockSpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (m *mockTracer) StartSpan(name string, parent Span) Span {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	span := &mockSpan{name: name, attributes: make(map[string]interface{})}
+	if parent != nil {
+		span.parent = parent.(*mockSpan)
+	}
+	m.spans = append(m.spans, span)
+	return span
+}
+
+func (s *mockSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *mockSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *mockSpan) End() {
+	s.ended = true
+}
+
+func assertSpanNames(t *testing.T, spans []*mockSpan, expected []string) {
+	actual := make([]string, len(spans))
+	for i, span := range spans {
+		actual[i] = span.name
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Unexpected spans\n\texpected: %+v\n\tactual: %+v",
+			expected,
+			actual)
+	}
+}
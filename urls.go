@@ -0,0 +1,273 @@
+package goff
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URLBuilder builds the URLs of resources and collections in the Yahoo fantasy
+// sports API, for use with GetFantasyContent. Each method returns a new
+// URLBuilder, so a partially built URL can be reused:
+//
+//    league := goff.NewURLBuilder(goff.YahooBaseURL).League("223.l.431")
+//    league.Teams().Stats().Type("week").Week(3).String()
+//    // https://fantasysports.yahooapis.com/fantasy/v2/league/223.l.431/teams/stats;type=week;week=3
+//    league.Out("standings", "settings").String()
+//    // https://fantasysports.yahooapis.com/fantasy/v2/league/223.l.431;out=standings,settings
+//
+// Keys and parameter values are escaped, so they can't change the structure
+// of the URL.
+//
+// See http://developer.yahoo.com/fantasysports/guide/ for the resources,
+// collections, and parameters supported by the API.
+type URLBuilder struct {
+	base     string
+	segments []urlSegment
+}
+
+// urlSegment is a single segment of the path of a URL, with its matrix
+// parameters, e.g. "players;player_keys=223.p.8261".
+type urlSegment struct {
+	name   string
+	params []urlParam
+}
+
+// urlParam is a matrix parameter with a list of values.
+type urlParam struct {
+	key    string
+	values []string
+}
+
+// NewURLBuilder returns a URLBuilder for URLs relative to the given base URL,
+// e.g. YahooBaseURL.
+func NewURLBuilder(baseURL string) URLBuilder {
+	return URLBuilder{base: strings.TrimRight(baseURL, "/")}
+}
+
+//
+// Resources
+//
+
+// Game adds the game with the given key, e.g. "nfl" or "223".
+func (b URLBuilder) Game(gameKey string) URLBuilder {
+	return b.Resource("game", gameKey)
+}
+
+// League adds the league with the given key, e.g. "223.l.431".
+func (b URLBuilder) League(leagueKey string) URLBuilder {
+	return b.Resource("league", leagueKey)
+}
+
+// Team adds the team with the given key, e.g. "223.l.431.t.1".
+func (b URLBuilder) Team(teamKey string) URLBuilder {
+	return b.Resource("team", teamKey)
+}
+
+// Player adds the player with the given key, e.g. "223.p.5479".
+func (b URLBuilder) Player(playerKey string) URLBuilder {
+	return b.Resource("player", playerKey)
+}
+
+// Transaction adds the transaction with the given key, e.g.
+// "223.l.431.tr.26".
+func (b URLBuilder) Transaction(transactionKey string) URLBuilder {
+	return b.Resource("transaction", transactionKey)
+}
+
+// Resource adds the resource of the given type with the given key.
+func (b URLBuilder) Resource(resource string, key string) URLBuilder {
+	return b.Sub(resource).Sub(key)
+}
+
+//
+// Collections
+//
+
+// Games adds the collection of games with the given keys, or every game when
+// no keys are given.
+func (b URLBuilder) Games(gameKeys ...string) URLBuilder {
+	return b.Collection("games", "game_keys", gameKeys...)
+}
+
+// Leagues adds the collection of leagues with the given keys, or every league
+// of the preceding resource when no keys are given.
+func (b URLBuilder) Leagues(leagueKeys ...string) URLBuilder {
+	return b.Collection("leagues", "league_keys", leagueKeys...)
+}
+
+// Teams adds the collection of teams with the given keys, or every team of the
+// preceding resource when no keys are given.
+func (b URLBuilder) Teams(teamKeys ...string) URLBuilder {
+	return b.Collection("teams", "team_keys", teamKeys...)
+}
+
+// Players adds the collection of players with the given keys. The
+// "player_keys" parameter is added even when no keys are given, which requests
+// no players.
+//
+// See AllPlayers
+func (b URLBuilder) Players(playerKeys ...string) URLBuilder {
+	return b.Sub("players").Param("player_keys", playerKeys...)
+}
+
+// AllPlayers adds the collection of every player of the preceding resource,
+// e.g. the players of a league or a team's roster. The API returns these
+// players in pages of 25, see the "start" and "count" parameters.
+func (b URLBuilder) AllPlayers() URLBuilder {
+	return b.Sub("players")
+}
+
+// Transactions adds the collection of transactions with the given keys, or
+// every transaction of the preceding resource when no keys are given.
+func (b URLBuilder) Transactions(transactionKeys ...string) URLBuilder {
+	return b.Collection("transactions", "transaction_keys", transactionKeys...)
+}
+
+// Users adds the collection containing the user who is currently logged in,
+// "users;use_login=1".
+func (b URLBuilder) Users() URLBuilder {
+	return b.Sub("users").Param("use_login", "1")
+}
+
+// Collection adds the collection with the given name, filtered to the given
+// keys using the parameter with the given name if any keys are given.
+func (b URLBuilder) Collection(
+	collection string,
+	keysParam string,
+	keys ...string) URLBuilder {
+
+	b = b.Sub(collection)
+	if len(keys) == 0 {
+		return b
+	}
+	return b.Param(keysParam, keys...)
+}
+
+//
+// Sub-resources
+//
+
+// Metadata adds the "metadata" sub-resource.
+func (b URLBuilder) Metadata() URLBuilder {
+	return b.Sub("metadata")
+}
+
+// Settings adds the "settings" sub-resource of a league.
+func (b URLBuilder) Settings() URLBuilder {
+	return b.Sub("settings")
+}
+
+// Standings adds the "standings" sub-resource of a league or team.
+func (b URLBuilder) Standings() URLBuilder {
+	return b.Sub("standings")
+}
+
+// Scoreboard adds the "scoreboard" sub-resource of a league.
+func (b URLBuilder) Scoreboard() URLBuilder {
+	return b.Sub("scoreboard")
+}
+
+// Roster adds the "roster" sub-resource of a team.
+func (b URLBuilder) Roster() URLBuilder {
+	return b.Sub("roster")
+}
+
+// Stats adds the "stats" sub-resource of a team or player.
+func (b URLBuilder) Stats() URLBuilder {
+	return b.Sub("stats")
+}
+
+// Matchups adds the "matchups" sub-resource of a team.
+func (b URLBuilder) Matchups() URLBuilder {
+	return b.Sub("matchups")
+}
+
+// Sub adds a path segment with the given name, such as a sub-resource that
+// does not have its own method.
+func (b URLBuilder) Sub(name string) URLBuilder {
+	segments := make([]urlSegment, len(b.segments), len(b.segments)+1)
+	copy(segments, b.segments)
+	b.segments = append(segments, urlSegment{name: name})
+	return b
+}
+
+//
+// Parameters
+//
+
+// Out adds the "out" parameter, including the given sub-resources in the
+// response of the preceding resource.
+func (b URLBuilder) Out(subresources ...string) URLBuilder {
+	return b.Param("out", subresources...)
+}
+
+// Week adds the "week" parameter.
+func (b URLBuilder) Week(week int) URLBuilder {
+	return b.Param("week", strconv.Itoa(week))
+}
+
+// Weeks adds the "week" parameter with a list of weeks, as used by
+// scoreboards. The parameter is not added when no weeks are given.
+func (b URLBuilder) Weeks(weeks ...int) URLBuilder {
+	if len(weeks) == 0 {
+		return b
+	}
+	return b.Param("week", itoas(weeks)...)
+}
+
+// MatchupWeeks adds the "weeks" parameter, as used by team matchups. The
+// parameter is not added when no weeks are given.
+func (b URLBuilder) MatchupWeeks(weeks ...int) URLBuilder {
+	if len(weeks) == 0 {
+		return b
+	}
+	return b.Param("weeks", itoas(weeks)...)
+}
+
+// Type adds the "type" parameter, e.g. "week" or "season" for stats.
+func (b URLBuilder) Type(statType string) URLBuilder {
+	return b.Param("type", statType)
+}
+
+// Param adds a matrix parameter to the last path segment, with the given
+// values separated by commas. Adding a parameter before any path segment has
+// no effect.
+func (b URLBuilder) Param(key string, values ...string) URLBuilder {
+	if len(b.segments) == 0 {
+		return b
+	}
+	segments := append([]urlSegment{}, b.segments...)
+	last := &segments[len(segments)-1]
+	last.params = append(
+		append([]urlParam{}, last.params...),
+		urlParam{key: key, values: values})
+	b.segments = segments
+	return b
+}
+
+// String returns the escaped URL.
+func (b URLBuilder) String() string {
+	path := b.base
+	for _, segment := range b.segments {
+		path += "/" + url.PathEscape(segment.name)
+		for _, param := range segment.params {
+			values := make([]string, len(param.values))
+			for i, value := range param.values {
+				values[i] = url.PathEscape(value)
+			}
+			path += ";" + url.PathEscape(param.key) + "=" +
+				strings.Join(values, ",")
+		}
+	}
+	return path
+}
+
+// itoas formats each of the integers.
+func itoas(ints []int) []string {
+	strs := make([]string, len(ints))
+	for i, n := range ints {
+		strs[i] = strconv.Itoa(n)
+	}
+	return strs
+}
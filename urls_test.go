@@ -0,0 +1,140 @@
+package goff
+
+import "testing"
+
+//
+// Test URLBuilder
+//
+
+func TestURLBuilder(t *testing.T) {
+	base := NewURLBuilder("https://example.com/fantasy/v2/")
+	tests := []struct {
+		builder  URLBuilder
+		expected string
+	}{
+		{
+			base.Game("nfl"),
+			"https://example.com/fantasy/v2/game/nfl",
+		},
+		{
+			base.Games("nfl", "223").Leagues(),
+			"https://example.com/fantasy/v2/games;game_keys=nfl,223/leagues",
+		},
+		{
+			base.Users().Games("nfl").Leagues(),
+			"https://example.com/fantasy/v2/users;use_login=1/games;" +
+				"game_keys=nfl/leagues",
+		},
+		{
+			base.League("223.l.431").Out("standings", "settings"),
+			"https://example.com/fantasy/v2/league/223.l.431;" +
+				"out=standings,settings",
+		},
+		{
+			base.League("223.l.431").Players("223.p.1", "223.p.2").
+				Stats().Type("week").Week(3),
+			"https://example.com/fantasy/v2/league/223.l.431/players;" +
+				"player_keys=223.p.1,223.p.2/stats;type=week;week=3",
+		},
+		{
+			base.League("223.l.431").Scoreboard().Weeks(1, 2, 3),
+			"https://example.com/fantasy/v2/league/223.l.431/scoreboard;" +
+				"week=1,2,3",
+		},
+		{
+			base.Team("223.l.431.t.1").Matchups().MatchupWeeks(1, 2),
+			"https://example.com/fantasy/v2/team/223.l.431.t.1/matchups;" +
+				"weeks=1,2",
+		},
+		{
+			base.Team("223.l.431.t.1").Roster().Week(16).AllPlayers(),
+			"https://example.com/fantasy/v2/team/223.l.431.t.1/roster;" +
+				"week=16/players",
+		},
+		{
+			base.League("223.l.431").Players(),
+			"https://example.com/fantasy/v2/league/223.l.431/players;" +
+				"player_keys=",
+		},
+		{
+			base.League("223.l.431").Scoreboard().Weeks(),
+			"https://example.com/fantasy/v2/league/223.l.431/scoreboard",
+		},
+		{
+			base.Team("223.l.431.t.1").Matchups().MatchupWeeks(),
+			"https://example.com/fantasy/v2/team/223.l.431.t.1/matchups",
+		},
+		{
+			base.Player("223.p.5479").Stats().Type("season"),
+			"https://example.com/fantasy/v2/player/223.p.5479/stats;" +
+				"type=season",
+		},
+		{
+			base.Transaction("223.l.431.tr.26"),
+			"https://example.com/fantasy/v2/transaction/223.l.431.tr.26",
+		},
+		{
+			base.League("223.l.431").Transactions().Param("type", "add"),
+			"https://example.com/fantasy/v2/league/223.l.431/transactions;" +
+				"type=add",
+		},
+		{
+			base.League("223.l.431").Teams().Sub("draftresults"),
+			"https://example.com/fantasy/v2/league/223.l.431/teams/" +
+				"draftresults",
+		},
+		{
+			base.Param("ignored", "value"),
+			"https://example.com/fantasy/v2",
+		},
+	}
+	for _, test := range tests {
+		assertStringEquals(t, test.expected, test.builder.String())
+	}
+}
+
+func TestURLBuilderEscapes(t *testing.T) {
+	base := NewURLBuilder(YahooBaseURL)
+	tests := []struct {
+		builder  URLBuilder
+		expected string
+	}{
+		{
+			base.League("223.l.431/../team"),
+			YahooBaseURL + "/league/223.l.431%2F..%2Fteam",
+		},
+		{
+			base.League("223.l.431;out=settings?a"),
+			YahooBaseURL + "/league/223.l.431%3Bout=settings%3Fa",
+		},
+		{
+			base.League("223.l.431").Players("223.p.1,223.p.2;x"),
+			YahooBaseURL + "/league/223.l.431/players;" +
+				"player_keys=223.p.1%2C223.p.2%3Bx",
+		},
+		{
+			base.Game("nfl").Param("sort key", "name with spaces"),
+			YahooBaseURL + "/game/nfl;sort%20key=name%20with%20spaces",
+		},
+	}
+	for _, test := range tests {
+		assertStringEquals(t, test.expected, test.builder.String())
+	}
+}
+
+func TestURLBuilderReuse(t *testing.T) {
+	league := NewURLBuilder(YahooBaseURL).League("223.l.431").Out("settings")
+
+	league.Param("week", "1")
+	teams := league.Teams()
+	league.Out("standings")
+
+	assertStringEquals(
+		t,
+		YahooBaseURL+"/league/223.l.431;out=settings",
+		league.String())
+	assertStringEquals(
+		t,
+		YahooBaseURL+"/league/223.l.431;out=settings/teams",
+		teams.String())
+}